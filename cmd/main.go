@@ -3,22 +3,153 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/repl"
 	"thermal/replcmd/registry"
 	"thermal/session"
+	"time"
 
 	"golang.org/x/term"
 )
 
+const usage = `Usage:
+  thermal [options] <entry>
+      start the REPL
+  thermal [options] <command> [flags] <entry>
+      run one command and exit (e.g. thermal facts -e 'NetSales*' filing.xbrl)
+  thermal [options] cache list|prune|warm [flags]
+      manage the cache of remote files (no entry needed)
+
+  entry: EDINET filing .zip, report package (.xbri|.xbr|.zip), manifest.xml,
+         schema .xsd, instance .xbrl, Inline XBRL .xhtml or xBRL-JSON .json
+
+options:
+  -o format       default output format for all commands (yaml|json|jsonl|csv|tsv|table)
+  -p package.zip  load a taxonomy package and resolve its URLs offline via META-INF/catalog.xml
+                  (also read from THERMAL_TAXONOMY_PACKAGES, separated like PATH)
+                  EDINET_TAXONOMY_DIR is still honoured for compatibility: files under
+                  http://disclosure.edinet-fsa.go.jp/taxonomy/ are read from that directory
+                  when present there, otherwise fetched from the network
+  -offline        never access the network; fail on files missing from the cache (THERMAL_OFFLINE=1)
+  -timeout 30s    timeout of each HTTP request (THERMAL_HTTP_TIMEOUT)
+  -cache dir      cache directory for remote files (THERMAL_CACHE_DIR, default: user cache dir/thermal)
+  -strict         abort on broken arcs and locators instead of skipping them (THERMAL_STRICT=1);
+                  problems found while loading are listed by the diagnostics command`
+
+// EDINET_TAXONOMY_DIR で置き換える EDINET のタクソノミのURL
+const edinetTaxonomyURL = "http://disclosure.edinet-fsa.go.jp/taxonomy/"
+
+// 終了コード
+const (
+	exitOK    = 0
+	exitError = 1 // 読込み又はコマンドの実行に失敗
+	exitUsage = 2 // 引数の誤り
+)
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		fmt.Fprintln(os.Stdout, usage)
+		return exitOK
+	}
+
+	// 先頭の -o は全コマンドに共通の出力形式、-p は読み込むタクソノミパッケージ（複数指定可）
+	// -offline・-timeout・-cache はリモートのファイルの取得方法、-strict は壊れたアークの扱い
+	var format string
+	packages := filepath.SplitList(os.Getenv("THERMAL_TAXONOMY_PACKAGES"))
+	cache := parser.GetCacheConfig()
+	for len(args) > 1 && strings.HasPrefix(args[0], "-") {
+		name := "-" + strings.TrimLeft(args[0], "-")
+		if name == "-offline" {
+			cache.Offline = true
+			args = args[1:]
+			continue
+		}
+		if name == "-strict" {
+			parser.SetStrict(true)
+			args = args[1:]
+			continue
+		}
+		if name != "-o" && name != "-p" && name != "-timeout" && name != "-cache" {
+			break
+		}
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			return exitUsage
+		}
+		value := args[1]
+		switch name {
+		case "-o":
+			if err := output.ValidateFormat(value); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return exitUsage
+			}
+			format = value
+		case "-p":
+			packages = append(packages, value)
+		case "-timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return exitUsage
+			}
+			cache.Timeout = d
+		case "-cache":
+			cache.Dir = value
+		}
+		args = args[2:]
+	}
+	parser.SetCacheConfig(cache)
+
+	// 互換のため、展開済みの EDINET タクソノミのディレクトリも書き換え規則として登録する
+	// ディレクトリに無いファイルは従来どおりネットワークから取得する
+	if dir := os.Getenv("EDINET_TAXONOMY_DIR"); dir != "" {
+		parser.AddTaxonomyDirectory(edinetTaxonomyURL, dir)
+	}
+	for _, pkg := range packages {
+		if _, err := parser.LoadTaxonomyPackage(pkg); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return exitError
+		}
+	}
+
+	registry.RegisterAll()
 
-	if len(os.Args) < 2 {
-		fmt.Fprintln(os.Stderr, "Usage: thermal <manifest.xml>|<schema.xsd>|<instance.xbrl>")
-		os.Exit(1)
+	// エントリーファイルの要らないコマンドは、引数をすべてコマンドに渡す
+	// 同じ名前のファイルだけが指定されたときはエントリーファイルとして扱う
+	if cmd, ok := registry.Lookup(args[0]); ok && registry.IsEntryLess(cmd) {
+		if info, err := os.Stat(args[0]); len(args) > 1 || err != nil || !info.Mode().IsRegular() {
+			return runCommand(args[0], args[1:], "", format)
+		}
+	}
+
+	// エントリーファイルだけなら対話モード、コマンド名が先頭にあれば1回だけ実行する
+	if len(args) == 1 {
+		if _, err := os.Stat(args[0]); err != nil {
+			if _, ok := registry.Lookup(args[0]); ok {
+				fmt.Fprintf(os.Stderr, "missing entry file for %s\n%s\n", args[0], usage)
+				return exitUsage
+			}
+		}
+		return startRepl(args[0], format)
 	}
+	return runCommand(args[0], args[1:len(args)-1], args[len(args)-1], format)
+}
 
+func startRepl(entryFile, format string) int {
 	var session session.Session
+	session.Format = format
 	// 対話モードか判定し、出力先を設定
 	isTerminal := term.IsTerminal(int(os.Stdin.Fd()))
 	session.Stdin = os.Stdin
@@ -29,20 +160,80 @@ func main() {
 		session.Stderr = os.Stderr
 	}
 
-	entryFile := os.Args[1]
+	if err := load(&session, entryFile); err != nil {
+		fmt.Fprintln(session.Stderr, err)
+		return exitError
+	}
+
+	repl.Start(&session)
+	return exitOK
+}
+
+func runCommand(name string, args []string, entryFile, format string) int {
+	cmd, ok := registry.Lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n%s\n", name, usage)
+		return exitUsage
+	}
+
+	session := session.Session{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Format: format,
+	}
+
+	if entryFile != "" {
+		if err := load(&session, entryFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	// 引数はシェルが区切ったまま渡すので、空白を含むパターンも指定できる
+	if err := cmd.Execute(&session, args); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// エントリーファイルの種類を判定して読み込む
+func load(session *session.Session, entryFile string) error {
+
+	// EDINET の提出書類のZIPファイルとレポートパッケージは展開せずに読み込む
+	if parser.IsArchive(entryFile) {
+		manifest, err := parser.ParseArchive(entryFile)
+		if err != nil {
+			return fmt.Errorf("failed to load archive: %v", err)
+		}
+		session.Manifest = manifest
+		session.Instance = manifest.List.XBRLInstances[0]
+		session.Schema = manifest.List.XBRLInstances[0].SchemaRefs.Schema
+		return nil
+	}
+
+	// xBRL-JSON はXMLではないので拡張子で判定する
+	if strings.EqualFold(filepath.Ext(entryFile), ".json") {
+		instance, err := parser.ParseXBRLJSON(entryFile)
+		if err != nil {
+			return fmt.Errorf("failed to load xBRL-JSON: %v", err)
+		}
+		session.Instance = instance
+		session.Schema = instance.SchemaRefs.Schema
+		return nil
+	}
 
 	rootName, err := parser.PeekXMLRootElementName(entryFile)
 	if err != nil {
-		fmt.Fprintf(session.Stderr, "failed to load entry file: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to load entry file: %v", err)
 	}
 
 	switch rootName {
 	case "manifest":
 		manifest, err := parser.ParseManifest(entryFile)
 		if err != nil {
-			fmt.Fprintf(session.Stderr, "failed to load manifest: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load manifest: %v", err)
 		}
 
 		session.Manifest = manifest
@@ -51,8 +242,15 @@ func main() {
 	case "xbrl":
 		instance, err := parser.ParseInstance(entryFile)
 		if err != nil {
-			fmt.Fprintf(session.Stderr, "failed to load XBRL: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load XBRL: %v", err)
+		}
+		session.Instance = instance
+		session.Schema = instance.SchemaRefs.Schema
+	case "html":
+		// 単独の InlineXBRL 文書（ESEF の報告書など）
+		instance, err := parser.ParseInlineXBRLs([]string{entryFile}, entryFile)
+		if err != nil {
+			return fmt.Errorf("failed to load Inline XBRL: %v", err)
 		}
 		session.Instance = instance
 		session.Schema = instance.SchemaRefs.Schema
@@ -60,15 +258,11 @@ func main() {
 		visited := make(map[string]bool)
 		schema, err := parser.ParseSchema(entryFile, visited)
 		if err != nil {
-			fmt.Fprintf(session.Stderr, "failed to load schema: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to load schema: %v", err)
 		}
 		session.Schema = schema
 	default:
-		fmt.Fprintf(session.Stderr, "file unknown\n")
-		os.Exit(1)
+		return fmt.Errorf("file unknown")
 	}
-
-	registry.RegisterAll()
-	repl.Start(&session)
+	return nil
 }
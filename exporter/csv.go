@@ -3,10 +3,13 @@ package exporter
 import (
 	"bytes"
 	"encoding/csv"
+	"encoding/xml"
 	"fmt"
+	"sort"
 	"strings"
 	"thermal/model"
-	"thermal/parser"
+	"thermal/output"
+	"thermal/resolver"
 )
 
 // DTSのcsv形式文字列作成
@@ -18,18 +21,14 @@ func CsvDts(instance *model.XBRLInstance, withheader bool) (string, error) {
 		writer.Write([]string{"RefFrom", "RefType", "RefTo", "Stop"})
 	}
 
-	writer.Write([]string{instance.Path, "schemaRef", instance.SchemaRefs.Schema.Path})
+	writer.Write([]string{instance.Path, "schemaRef", instance.SchemaRefs.Schema.Path, ""})
 
 	// DTSツリーを展開
 	if err := writeDts(instance.SchemaRefs.Schema, writer); err != nil {
 		return "", err
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return flush(writer, &buf)
 }
 
 func writeDts(schema *model.XBRLSchema, writer *csv.Writer) error {
@@ -45,6 +44,9 @@ func writeDts(schema *model.XBRLSchema, writer *csv.Writer) error {
 	for _, linkbase := range schema.ReferencedLabelLinkbases {
 		writer.Write([]string{schema.Path, "labelLinkbaseRef", linkbase.Path, ""})
 	}
+	for _, linkbase := range schema.ReferencedReferenceLinkbases {
+		writer.Write([]string{schema.Path, "referenceLinkbaseRef", linkbase.Path, ""})
+	}
 	for _, linkbase := range schema.ReferencedGenericLinkbases {
 		writer.Write([]string{schema.Path, "linkbaseRef", linkbase.Path, ""})
 	}
@@ -62,32 +64,46 @@ func writeDts(schema *model.XBRLSchema, writer *csv.Writer) error {
 	return nil
 }
 
-// 全ロールタイプcsv形式文字列作成
-func CsvRoleTypes(schema *model.XBRLSchema, withheader bool) (string, error) {
-	var sb strings.Builder
-	if withheader {
-		sb.WriteString("Path,Id,TargetNamespace,RoleURI,Definition\n")
+// DTSのスキーマをインポート順にたどる
+// パーサーは取込み経路ごとにスキーマを読むため、同じパスのスキーマは1回だけ処理する
+func walkSchemas(schema *model.XBRLSchema, visited map[string]bool, fn func(*model.XBRLSchema) error) error {
+	if schema == nil || visited[schema.Path] {
+		return nil
 	}
+	visited[schema.Path] = true
 
-	for _, roleType := range schema.RoleTypes {
-		sb.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s\n", schema.Path, roleType.Id, schema.TargetNS, roleType.RoleURI, roleType.Definition.Value))
+	if err := fn(schema); err != nil {
+		return err
 	}
-
 	for _, child := range schema.Imports {
-		if child.Schema != nil {
-			csv, err := CsvRoleTypes(child.Schema, false)
-			if err != nil {
-				return "", err
-			}
-			sb.WriteString(csv)
+		if err := walkSchemas(child.Schema, visited, fn); err != nil {
+			return err
 		}
 	}
-	result := sb.String()
-	return result, nil
+	return nil
+}
+
+// 全ロールタイプcsv形式文字列作成
+func CsvRoleTypes(schema *model.XBRLSchema, withheader bool) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if withheader {
+		writer.Write([]string{"Path", "Id", "TargetNamespace", "RoleURI", "Definition"})
+	}
+
+	walkSchemas(schema, map[string]bool{}, func(s *model.XBRLSchema) error {
+		for _, roleType := range s.RoleTypes {
+			writer.Write([]string{s.Path, roleType.Id, s.TargetNS, roleType.RoleURI, roleType.Definition.Value})
+		}
+		return nil
+	})
+
+	return flush(writer, &buf)
 }
 
 // 全ジェネリックリンクcsv形式文字列作成
-func CsvGenericLinks(schema *model.XBRLSchema, roleTypes map[string]model.RoleType, withheader bool) (string, error) {
+func CsvGenericLinks(schema *model.XBRLSchema, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
@@ -97,330 +113,399 @@ func CsvGenericLinks(schema *model.XBRLSchema, roleTypes map[string]model.RoleTy
 		})
 	}
 
-	if err := writeGenericLinks(schema, roleTypes, writer); err != nil {
+	roleTypes := make(map[string]*model.RoleType)
+	resolver.CollectRoleTypesByHref(schema, roleTypes)
+	grouped, err := resolver.TraverseGenericLink(schema, roleTypes)
+	if err != nil {
 		return "", err
 	}
 
-	// 子スキーマも再帰的に処理（ヘッダーなし）
-	for _, child := range schema.Imports {
-		if child.Schema != nil {
-			csvStr, err := CsvGenericLinks(child.Schema, roleTypes, false)
-			if err != nil {
-				return "", err
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			rt, ok := rel.From.(*model.RoleType)
+			if !ok {
+				continue
+			}
+			label, ok := rel.To.(*model.GenericLabel)
+			if !ok {
+				continue
 			}
-			buf.WriteString(csvStr)
+			writer.Write([]string{
+				rt.Schema.Path,
+				rt.Id,
+				rt.Schema.TargetNS,
+				rt.RoleURI,
+				rt.Definition.Value,
+				label.Value,
+			})
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return flush(writer, &buf)
 }
 
-func writeGenericLinks(schema *model.XBRLSchema, roleTypes map[string]model.RoleType, writer *csv.Writer) error {
-	for _, linkbase := range schema.ReferencedGenericLinkbases {
-		for _, elr := range linkbase.GenericLinks {
-			locMap := make(map[string]model.Loc)
-			for _, loc := range elr.Locs {
-				locMap[loc.Label] = loc
-			}
-			labelMap := make(map[string]model.GenericLabel)
-			for _, label := range elr.Labels {
-				labelMap[label.Label] = label
+// 全要素csv形式文字列作成
+// labels を指定したときは末尾にラベル列を追加する
+func CsvElements(schema *model.XBRLSchema, labels *resolver.LabelResolver, lang string, withheader bool) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if withheader {
+		header := []string{
+			"Path", "Id", "TargetNamespace", "Name", "Type", "SubstitutionGroup",
+			"Abstract", "Nillable", "PeriodType",
+		}
+		if labels != nil {
+			header = append(header, "Label")
+		}
+		writer.Write(header)
+	}
+
+	walkSchemas(schema, map[string]bool{}, func(s *model.XBRLSchema) error {
+		for i, element := range s.Elements {
+			record := []string{
+				s.Path,
+				element.Id,
+				s.TargetNS,
+				element.Name,
+				element.Type,
+				element.SubstitutionGroup,
+				element.Abstract,
+				element.Nillable,
+				element.PeriodType,
 			}
-			for _, arc := range elr.Arcs {
-				loc, ok := locMap[arc.From]
-				if !ok {
-					return fmt.Errorf("Arc invalid: from=%s", arc.From)
-				}
-				label, ok := labelMap[arc.To]
-				if !ok {
-					return fmt.Errorf("Arc invalid: to=%s", arc.To)
-				}
-				key := parser.ResolveHref(linkbase.Path, loc.Href)
-				rt, ok := roleTypes[key]
-				if !ok {
-					return fmt.Errorf("Loc invalid: %s", key)
-				}
-				record := []string{
-					rt.Schema.Path,
-					rt.Id,
-					rt.Schema.TargetNS,
-					rt.RoleURI,
-					rt.Definition.Value,
-					label.Value,
-				}
-				writer.Write(record)
+			if labels != nil {
+				record = append(record, labels.Label(&s.Elements[i], "", lang))
 			}
+			writer.Write(record)
 		}
-	}
-	return nil
+		return nil
+	})
+
+	return flush(writer, &buf)
 }
 
-// 全要素csv形式文字列作成
-func CsvElements(schema *model.XBRLSchema, withheader bool) (string, error) {
+// DTSの全ラベルcsv形式文字列作成
+// 禁止・優先度を適用した後の有効なラベルを出力する
+func CsvLabels(schema *model.XBRLSchema, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
 	if withheader {
 		writer.Write([]string{
-			"Path", "Id", "TargetNamespace", "Name", "Type", "SubstitutionGroup",
-			"Abstract", "Nillable", "PeriodType",
+			"TargetNamespace", "Name", "URI", "Id", "Lang", "Value", "Role",
 		})
 	}
 
-	for _, element := range schema.Elements {
-		record := []string{
-			schema.Path,
-			element.Id,
-			schema.TargetNS,
-			element.Name,
-			element.Type,
-			element.SubstitutionGroup,
-			element.Abstract,
-			element.Nillable,
-			element.PeriodType,
-		}
-		writer.Write(record)
-	}
-
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	grouped, err := resolver.TraverseLabelLink(schema)
+	if err != nil {
 		return "", err
 	}
 
-	for _, child := range schema.Imports {
-		if child.Schema != nil {
-			childCSV, err := CsvElements(child.Schema, false)
-			if err != nil {
-				return "", err
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			elem, ok := rel.From.(*model.XMLElement)
+			if !ok {
+				continue
+			}
+			label, ok := rel.To.(*model.LabelLabel)
+			if !ok {
+				continue
 			}
-			buf.WriteString(childCSV)
+			writer.Write(append(elementColumns(elem), label.Lang, label.Value, label.Role))
 		}
 	}
 
-	return buf.String(), nil
+	return flush(writer, &buf)
 }
 
-// DTSの全ラベルcsv形式文字列作成
-func CsvLabels(schema *model.XBRLSchema, elements map[string]*model.XMLElement, withheader bool) (string, error) {
+// DTSの全参照csv形式文字列作成
+func CsvReferences(schema *model.XBRLSchema, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
 	if withheader {
 		writer.Write([]string{
-			"TargetNamespace", "Name", "URI", "Id", "Lang", "Value", "Role",
+			"TargetNamespace", "Name", "URI", "Id", "Role",
+			"Publisher", "Number", "ReferenceName", "Article", "IssueDate", "IndustryAbbreviation",
 		})
 	}
 
-	// メインのラベルリンクベース処理
-	if err := writeLabels(schema, elements, writer); err != nil {
+	grouped, err := resolver.TraverseReferenceLink(schema)
+	if err != nil {
 		return "", err
 	}
 
-	// 再帰的にインポート先を処理（ヘッダー無しで）
-	for _, child := range schema.Imports {
-		if child.Schema != nil {
-			childCSV, err := CsvLabels(child.Schema, elements, false)
-			if err != nil {
-				return "", err
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			elem, ok := rel.From.(*model.XMLElement)
+			if !ok {
+				continue
 			}
-			buf.WriteString(childCSV)
+			ref, ok := rel.To.(*model.ReferenceReference)
+			if !ok {
+				continue
+			}
+			writer.Write(append(elementColumns(elem),
+				ref.Role,
+				ref.Publisher,
+				ref.Number,
+				ref.Name,
+				ref.Article,
+				ref.IssueDate,
+				ref.IndustryAbbreviation,
+			))
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	return flush(writer, &buf)
+}
+
+// DTSの表示リンクcsv形式文字列作成
+// labels を指定したときは末尾に親子のラベル列を追加する（子は preferredLabel のロール）
+func CsvPresentationLinks(schema *model.XBRLSchema, labels *resolver.LabelResolver, lang string, withheader bool) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if withheader {
+		header := append(relationHeader(), "Order", "PreferredLabel")
+		if labels != nil {
+			header = append(header, "FromLabel", "ToLabel")
+		}
+		writer.Write(header)
+	}
+
+	grouped, err := resolver.TraversePresentationLink(schema)
+	if err != nil {
 		return "", err
 	}
-	return buf.String(), nil
-}
 
-func writeLabels(schema *model.XBRLSchema, elements map[string]*model.XMLElement, writer *csv.Writer) error {
-	for _, linkbase := range schema.ReferencedLabelLinkbases {
-		for _, elr := range linkbase.LabelLinks {
-			locMap := map[string]model.Loc{}
-			for _, loc := range elr.Locs {
-				locMap[loc.Label] = loc
-			}
-			labelMap := map[string]model.LabelLabel{}
-			for _, label := range elr.Labels {
-				labelMap[label.Label] = label
-			}
-			for _, arc := range elr.Arcs {
-				loc, ok := locMap[arc.From]
-				if !ok {
-					return fmt.Errorf("Arc invalid: from=%s", arc.From)
-				}
-				label, ok := labelMap[arc.To]
-				if !ok {
-					return fmt.Errorf("Arc invalid: to=%s", arc.To)
-				}
-				key := parser.ResolveHref(linkbase.Path, loc.Href)
-				elem, ok := elements[key]
-				if !ok {
-					return fmt.Errorf("Loc invalid: %s", key)
-				}
-				writer.Write([]string{
-					elem.Schema.TargetNS,
-					elem.Name,
-					elem.Schema.Path,
-					elem.Id,
-					label.Lang,
-					label.Value,
-					label.Role,
-				})
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			arc := rel.Arc.(*model.PresentationArc)
+			from, to := rel.From.(*model.XMLElement), rel.To.(*model.XMLElement)
+
+			record := append(relationColumns(role, from, to), arc.Order, arc.PreferredLabel)
+			if labels != nil {
+				record = append(record, labels.Label(from, "", lang), labels.Label(to, arc.PreferredLabel, lang))
 			}
+			writer.Write(record)
 		}
 	}
-	return nil
+
+	return flush(writer, &buf)
 }
 
-// DTSの表示リンクcsv形式文字列作成
-func CsvPresentationLinks(schema *model.XBRLSchema, elements map[string]*model.XMLElement, withheader bool) (string, error) {
+// DTSの定義リンクcsv形式文字列作成
+// labels を指定したときは末尾に親子のラベル列を追加する
+func CsvDefinitionLinks(schema *model.XBRLSchema, labels *resolver.LabelResolver, lang string, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
 	if withheader {
-		writer.Write([]string{
-			"Role", "FromTargetNamespace", "FromName", "FromURI", "FromId",
-			"ToTargetNamespace", "ToName", "ToURI", "ToId", "Order", "PreferredLabel",
-		})
+		header := append(relationHeader(), "ArcRole", "Order", "TargetRole", "Closed", "ContextElement", "Usable")
+		if labels != nil {
+			header = append(header, "FromLabel", "ToLabel")
+		}
+		writer.Write(header)
 	}
 
-	for _, linkbase := range schema.ReferencedPresentationLinkbases {
-		for _, elr := range linkbase.PresentationLinks {
-			locMap := make(map[string]model.Loc)
-			for _, _loc := range elr.Locs {
-				locMap[_loc.Label] = _loc
-			}
+	grouped, err := resolver.TraverseDefinitionLink(schema)
+	if err != nil {
+		return "", err
+	}
 
-			for _, arc := range elr.Arcs {
-				locFrom, exists := locMap[arc.From]
-				if !exists {
-					return "", fmt.Errorf("Arc invalid: from=%s", arc.From)
-				}
-				locTo, exists := locMap[arc.To]
-				if !exists {
-					return "", fmt.Errorf("Arc invalid: to=%s", arc.To)
-				}
-
-				keyFrom := parser.ResolveHref(linkbase.Path, locFrom.Href)
-				elemFrom, exists := elements[keyFrom]
-				if !exists {
-					return "", fmt.Errorf("Loc invalid: %s", keyFrom)
-				}
-				keyTo := parser.ResolveHref(linkbase.Path, locTo.Href)
-				elemTo, exists := elements[keyTo]
-				if !exists {
-					return "", fmt.Errorf("Loc invalid: %s", keyTo)
-				}
-
-				record := []string{
-					elr.Role,
-					elemFrom.Schema.TargetNS,
-					elemFrom.Name,
-					elemFrom.Schema.Path,
-					elemFrom.Id,
-					elemTo.Schema.TargetNS,
-					elemTo.Name,
-					elemTo.Schema.Path,
-					elemTo.Id,
-					arc.Order,
-					arc.PreferredLabel,
-				}
-				writer.Write(record)
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			arc := rel.Arc.(*model.DefinitionArc)
+			from, to := rel.From.(*model.XMLElement), rel.To.(*model.XMLElement)
+
+			record := append(relationColumns(role, from, to),
+				arc.ArcRole, arc.Order, arc.TargetRole, arc.Closed, arc.ContextElement, arc.Usable)
+			if labels != nil {
+				record = append(record, labels.Label(from, "", lang), labels.Label(to, "", lang))
 			}
+			writer.Write(record)
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
+	return flush(writer, &buf)
+}
+
+// DTSの計算リンクcsv形式文字列作成
+// labels を指定したときは末尾に親子のラベル列を追加する
+func CsvCalculationLinks(schema *model.XBRLSchema, labels *resolver.LabelResolver, lang string, withheader bool) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if withheader {
+		header := append(relationHeader(), "ArcRole", "Order", "Weight")
+		if labels != nil {
+			header = append(header, "FromLabel", "ToLabel")
+		}
+		writer.Write(header)
+	}
+
+	grouped, err := resolver.TraverseCalculationLink(schema)
+	if err != nil {
 		return "", err
 	}
-	return buf.String(), nil
+
+	for _, role := range sortedRoles(grouped) {
+		for _, rel := range grouped[role] {
+			arc := rel.Arc.(*model.CalculationArc)
+			from, to := rel.From.(*model.XMLElement), rel.To.(*model.XMLElement)
+
+			record := append(relationColumns(role, from, to),
+				arc.ArcRole, arc.Order, strings.TrimSpace(arc.Weight))
+			if labels != nil {
+				record = append(record, labels.Label(from, "", lang), labels.Label(to, "", lang))
+			}
+			writer.Write(record)
+		}
+	}
+
+	return flush(writer, &buf)
 }
 
-func sanitizeLongValue(input string) string {
-	// 改行をすべて削除（CR, LF 両方対応）
-	noNewlines := strings.ReplaceAll(input, "\r", "")
-	noNewlines = strings.ReplaceAll(noNewlines, "\n", "")
+// 要素を示す列（名前空間・名前・スキーマ・ID）
+func elementColumns(elem *model.XMLElement) []string {
+	return []string{elem.Schema.TargetNS, elem.Name, elem.Schema.Path, elem.Id}
+}
 
-	// 先頭100文字を取得（rune単位で安全に）
-	runes := []rune(noNewlines)
-	if len(runes) > 100 {
-		runes = runes[:100]
+// 関係の共通列のヘッダー
+func relationHeader() []string {
+	return []string{
+		"Role", "FromTargetNamespace", "FromName", "FromURI", "FromId",
+		"ToTargetNamespace", "ToName", "ToURI", "ToId",
 	}
-	head := string(runes)
+}
 
-	return head
+// 関係の共通列（ELR・起点の要素・終点の要素）
+func relationColumns(role string, from, to *model.XMLElement) []string {
+	record := []string{role}
+	record = append(record, elementColumns(from)...)
+	return append(record, elementColumns(to)...)
+}
+
+// ELRの出力順を固定する
+func sortedRoles(grouped map[string][]resolver.ArcRelation) []string {
+	roles := make([]string, 0, len(grouped))
+	for role := range grouped {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
 }
 
 // 全ファクトcsv形式文字列作成
-func CsvFacts(instance *model.XBRLInstance, withheader bool) (string, error) {
+// labels を指定したときは末尾にラベル列を追加する
+func CsvFacts(instance *model.XBRLInstance, labels *resolver.LabelResolver, lang string, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
 	if withheader {
-		writer.Write([]string{
+		header := []string{
 			"TargetNamespace", "Name", "Value", "ContextRef", "Decimals", "UnitRef", "Nil",
-		})
+		}
+		if labels != nil {
+			header = append(header, "Label")
+		}
+		writer.Write(header)
 	}
 
 	for _, fact := range instance.Facts {
 		record := []string{
 			fact.XMLName.Space,
 			fact.XMLName.Local,
-			sanitizeLongValue(fact.Value),
+			output.SanitizeLongValue(fact.Value, 100, ""),
 			fact.ContextRef,
 			fact.Decimals,
 			fact.UnitRef,
 			fact.Nil,
 		}
+		if labels != nil {
+			record = append(record, labels.LabelByName(fact.XMLName, "", lang))
+		}
 		writer.Write(record)
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return flush(writer, &buf)
 }
 
 // 全コンテキストcsv形式文字列作成
+// ディメンションの列数は、最も多くのディメンションを持つコンテキストに合わせる
 func CsvContexts(instance *model.XBRLInstance, withheader bool) (string, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	if withheader {
-		writer.Write([]string{
-			"Id", "Identifier", "StartDate", "EndDate", "Instant",
-			"Dimension-1", "Member-1", "Dimension-2", "Member-2", "Dimension-3", "Member-3",
-		})
+	maxDims := 0
+	for i := range instance.Contexts {
+		maxDims = max(maxDims, len(instance.Contexts[i].Dimensions()))
 	}
 
-	for _, context := range instance.Contexts {
-		var dims, mems [3]string
-		for i := 0; i < len(context.Scenario.Members) && i < 3; i++ {
-			dims[i] = context.Scenario.Members[i].Dimension
-			mems[i] = context.Scenario.Members[i].Value
+	if withheader {
+		header := []string{"Id", "Identifier", "StartDate", "EndDate", "Instant"}
+		for i := 1; i <= maxDims; i++ {
+			header = append(header, fmt.Sprintf("Dimension-%d", i), fmt.Sprintf("Member-%d", i))
 		}
+		writer.Write(header)
+	}
 
+	for _, context := range instance.Contexts {
 		record := []string{
 			context.ID,
 			context.Entity.Identifier.Value,
 			context.Period.StartDate,
 			context.Period.EndDate,
 			context.Period.Instant,
-			dims[0], mems[0],
-			dims[1], mems[1],
-			dims[2], mems[2],
+		}
+		dims := context.Dimensions()
+		for i := 0; i < maxDims; i++ {
+			if i < len(dims) {
+				record = append(record, dims[i].Dimension, dims[i].Member)
+			} else {
+				record = append(record, "", "")
+			}
 		}
 		writer.Write(record)
 	}
 
+	return flush(writer, &buf)
+}
+
+// 全単位csv形式文字列作成
+// 分子・分母は {名前空間}ローカル名 を空白区切りで並べる
+func CsvUnits(instance *model.XBRLInstance, withheader bool) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if withheader {
+		writer.Write([]string{"Id", "Measure", "Numerator", "Denominator", "Canonical"})
+	}
+
+	for _, unit := range instance.Units {
+		writer.Write([]string{
+			unit.ID,
+			unit.Measure(),
+			clarkNames(unit.Numerator),
+			clarkNames(unit.Denominator),
+			unit.Canonical(),
+		})
+	}
+
+	return flush(writer, &buf)
+}
+
+func clarkNames(names []xml.Name) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("{%s}%s", name.Space, name.Local)
+	}
+	return strings.Join(parts, " ")
+}
+
+func flush(writer *csv.Writer, buf *bytes.Buffer) (string, error) {
 	writer.Flush()
 	if err := writer.Error(); err != nil {
 		return "", err
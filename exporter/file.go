@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Excel で UTF-8 と認識させるためのバイト順マーク
+const utf8BOM = "\xef\xbb\xbf"
+
+// csv形式文字列を書き出す。bom が true のときは先頭に BOM を付ける
+func Write(w io.Writer, content string, bom bool) error {
+	if bom {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, content)
+	return err
+}
+
+// csv形式文字列をファイルに書き出す
+func WriteFile(path string, content string, bom bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, content, bom); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ZIP内のパス → 内容 をZIPファイルに書き出す（パスの順に並べる）
+// BOM は csv ファイルにだけ付ける
+func WriteZip(path string, files map[string]string, bom bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := zip.NewWriter(f)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry, err := w.Create(name)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if err := Write(entry, files[name], bom && filepath.Ext(name) == ".csv"); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,360 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"thermal/model"
+	"thermal/parser"
+)
+
+// インスタンス文書で使う名前空間
+const (
+	nsLink   = "http://www.xbrl.org/2003/linkbase"
+	nsXLink  = "http://www.w3.org/1999/xlink"
+	nsXSI    = "http://www.w3.org/2001/XMLSchema-instance"
+	nsXBRLDI = "http://xbrl.org/2006/xbrldi"
+	nsXHTML  = "http://www.w3.org/1999/xhtml"
+)
+
+// インスタンス文書を書き出すときのプレフィックスの対応
+type instanceWriter struct {
+	b        strings.Builder
+	prefixes map[string]string // 名前空間URI → プレフィックス
+	declared map[string]string // プレフィックス → 名前空間URI
+}
+
+// 名前空間のプレフィックスを返す。未宣言なら preferred（使用済みなら連番付き）で宣言する
+func (w *instanceWriter) prefix(uri, preferred string) string {
+	if p, ok := w.prefixes[uri]; ok {
+		return p
+	}
+	candidate := preferred
+	for i := 1; ; i++ {
+		if _, ok := w.declared[candidate]; !ok {
+			break
+		}
+		candidate = fmt.Sprintf("%s%d", preferred, i)
+	}
+	w.prefixes[uri] = candidate
+	w.declared[candidate] = uri
+	return candidate
+}
+
+func (w *instanceWriter) name(uri, preferred, local string) string {
+	return w.prefix(uri, preferred) + ":" + local
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// 属性を書く（値が空なら書かない）
+func (w *instanceWriter) attr(name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(&w.b, ` %s="%s"`, name, escapeXML(value))
+}
+
+// インスタンスを XBRL 2.1 のインスタンス文書（XML）にする
+// outputFile を指定すると、schemaRef と roleRef の相対パスを出力先から参照できるパスに書き直す
+func XBRLInstanceXML(instance *model.XBRLInstance, outputFile string) (string, error) {
+	w := &instanceWriter{
+		prefixes: make(map[string]string),
+		declared: make(map[string]string),
+	}
+
+	// インスタンスで宣言されたプレフィックスはそのまま使う（QName の値がそのプレフィックスで書かれているため）
+	declared := make([]string, 0, len(instance.Namespaces))
+	for prefix := range instance.Namespaces {
+		if prefix != "(default)" {
+			declared = append(declared, prefix)
+		}
+	}
+	sort.Strings(declared)
+	for _, prefix := range declared {
+		if _, ok := w.prefixes[instance.Namespaces[prefix]]; !ok {
+			w.prefixes[instance.Namespaces[prefix]] = prefix
+		}
+		w.declared[prefix] = instance.Namespaces[prefix]
+	}
+
+	xbrli := func(local string) string { return w.name(nsXBRLI, "xbrli", local) }
+	link := func(local string) string { return w.name(nsLink, "link", local) }
+	xlink := func(local string) string { return w.name(nsXLink, "xlink", local) }
+
+	// 本体を先に書き、使った名前空間をルート要素で宣言する
+	w.b.WriteString("\n")
+	w.b.WriteString(" <" + link("schemaRef"))
+	w.attr(xlink("type"), "simple")
+	w.attr(xlink("href"), relocateHref(instance.Path, instance.SchemaRefs.Href, outputFile))
+	w.b.WriteString("/>\n")
+
+	for _, ref := range instance.RoleRefs {
+		w.b.WriteString(" <" + link("roleRef"))
+		w.attr("roleURI", ref.RoleURI)
+		w.attr(xlink("type"), "simple")
+		w.attr(xlink("href"), relocateHref(instance.Path, ref.Href, outputFile))
+		w.b.WriteString("/>\n")
+	}
+
+	for i := range instance.Contexts {
+		w.writeContext(&instance.Contexts[i])
+	}
+	for i := range instance.Units {
+		w.writeUnit(&instance.Units[i])
+	}
+	for i := range instance.Facts {
+		if err := w.writeFact(&instance.Facts[i]); err != nil {
+			return "", err
+		}
+	}
+	for i := range instance.FootnoteLink {
+		w.writeFootnoteLink(&instance.FootnoteLink[i])
+	}
+	w.b.WriteString("</" + xbrli("xbrl") + ">\n")
+
+	var root strings.Builder
+	root.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	root.WriteString("<" + xbrli("xbrl"))
+	prefixes := make([]string, 0, len(w.declared))
+	for prefix := range w.declared {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	if uri, ok := instance.Namespaces["(default)"]; ok && uri != nsXHTML {
+		fmt.Fprintf(&root, ` xmlns="%s"`, escapeXML(uri))
+	}
+	for _, prefix := range prefixes {
+		fmt.Fprintf(&root, ` xmlns:%s="%s"`, prefix, escapeXML(w.declared[prefix]))
+	}
+	root.WriteString(">")
+	return root.String() + w.b.String(), nil
+}
+
+func (w *instanceWriter) writeContext(context *model.Context) {
+	xbrli := func(local string) string { return w.name(nsXBRLI, "xbrli", local) }
+
+	w.b.WriteString(" <" + xbrli("context"))
+	w.attr("id", context.ID)
+	w.b.WriteString("><" + xbrli("entity") + "><" + xbrli("identifier"))
+	w.attr("scheme", strings.TrimSpace(context.Entity.Identifier.Scheme))
+	w.b.WriteString(">" + escapeXML(strings.TrimSpace(context.Entity.Identifier.Value)) + "</" + xbrli("identifier") + ">")
+	w.writeMembers(xbrli("segment"), context.Entity.Segment.Members, context.Entity.Segment.TypedMembers)
+	w.b.WriteString("</" + xbrli("entity") + "><" + xbrli("period") + ">")
+
+	period := context.Period
+	switch {
+	case strings.TrimSpace(period.Instant) != "":
+		w.b.WriteString("<" + xbrli("instant") + ">" + escapeXML(strings.TrimSpace(period.Instant)) + "</" + xbrli("instant") + ">")
+	case strings.TrimSpace(period.StartDate) != "" || strings.TrimSpace(period.EndDate) != "":
+		w.b.WriteString("<" + xbrli("startDate") + ">" + escapeXML(strings.TrimSpace(period.StartDate)) + "</" + xbrli("startDate") + ">")
+		w.b.WriteString("<" + xbrli("endDate") + ">" + escapeXML(strings.TrimSpace(period.EndDate)) + "</" + xbrli("endDate") + ">")
+	default:
+		w.b.WriteString("<" + xbrli("forever") + "/>")
+	}
+	w.b.WriteString("</" + xbrli("period") + ">")
+
+	w.writeMembers(xbrli("scenario"), context.Scenario.Members, context.Scenario.TypedMembers)
+	w.b.WriteString("</" + xbrli("context") + ">\n")
+}
+
+// segment 又は scenario のディメンションを書く（無ければ要素ごと書かない）
+func (w *instanceWriter) writeMembers(element string, members []model.Member, typedMembers []model.TypedMember) {
+	if len(members) == 0 && len(typedMembers) == 0 {
+		return
+	}
+	xbrldi := func(local string) string { return w.name(nsXBRLDI, "xbrldi", local) }
+
+	w.b.WriteString("<" + element + ">")
+	for _, m := range members {
+		w.b.WriteString("<" + xbrldi("explicitMember"))
+		w.attr("dimension", strings.TrimSpace(m.Dimension))
+		w.b.WriteString(">" + escapeXML(strings.TrimSpace(m.Value)) + "</" + xbrldi("explicitMember") + ">")
+	}
+	for _, m := range typedMembers {
+		// 型付きメンバーの子要素はXMLのまま書く
+		w.b.WriteString("<" + xbrldi("typedMember"))
+		w.attr("dimension", strings.TrimSpace(m.Dimension))
+		w.b.WriteString(">" + strings.TrimSpace(m.Value) + "</" + xbrldi("typedMember") + ">")
+	}
+	w.b.WriteString("</" + element + ">")
+}
+
+func (w *instanceWriter) writeUnit(unit *model.Unit) {
+	xbrli := func(local string) string { return w.name(nsXBRLI, "xbrli", local) }
+	measures := func(values []string) {
+		for _, m := range values {
+			w.b.WriteString("<" + xbrli("measure") + ">" + escapeXML(strings.TrimSpace(m)) + "</" + xbrli("measure") + ">")
+		}
+	}
+
+	w.b.WriteString(" <" + xbrli("unit"))
+	w.attr("id", unit.ID)
+	w.b.WriteString(">")
+	if len(unit.Divide.Numerator) > 0 {
+		w.b.WriteString("<" + xbrli("divide") + "><" + xbrli("unitNumerator") + ">")
+		measures(unit.Divide.Numerator)
+		w.b.WriteString("</" + xbrli("unitNumerator") + "><" + xbrli("unitDenominator") + ">")
+		measures(unit.Divide.Denominator)
+		w.b.WriteString("</" + xbrli("unitDenominator") + "></" + xbrli("divide") + ">")
+	} else {
+		measures(unit.Measures)
+	}
+	w.b.WriteString("</" + xbrli("unit") + ">\n")
+}
+
+// ix:format の変換に失敗したファクトは正しい値が分からないので書き出さない
+func (w *instanceWriter) writeFact(fact *model.Fact) error {
+	if fact.TransformError != "" {
+		return fmt.Errorf("fact %s (context %s) could not be transformed: %s", fact.XMLName.Local, fact.ContextRef, fact.TransformError)
+	}
+	name := w.name(fact.XMLName.Space, "ns", fact.XMLName.Local)
+	isNil := fact.Nil == "true" || fact.Nil == "1"
+
+	w.b.WriteString(" <" + name)
+	w.attr("contextRef", fact.ContextRef)
+	w.attr("unitRef", fact.UnitRef)
+	// nil のファクトには精度を付けない
+	if !isNil {
+		w.attr("decimals", strings.TrimSpace(fact.Decimals))
+	}
+	w.attr("id", fact.ID)
+	w.attr("xml:lang", fact.Lang)
+	if isNil {
+		w.attr(w.name(nsXSI, "xsi", "nil"), "true")
+		w.b.WriteString("/>\n")
+		return nil
+	}
+	w.b.WriteString(">" + escapeXML(fact.Value) + "</" + name + ">\n")
+	return nil
+}
+
+func (w *instanceWriter) writeFootnoteLink(fl *model.FootnoteLink) {
+	link := func(local string) string { return w.name(nsLink, "link", local) }
+	xlink := func(local string) string { return w.name(nsXLink, "xlink", local) }
+
+	w.b.WriteString(" <" + link("footnoteLink"))
+	w.attr(xlink("type"), "extended")
+	w.attr(xlink("role"), fl.Role)
+	w.b.WriteString(">\n")
+
+	for _, loc := range fl.Locs {
+		// ファクトは同じ文書内にあるので、フラグメントだけを参照する
+		href := loc.Href
+		if i := strings.Index(href, "#"); i >= 0 {
+			href = href[i:]
+		}
+		w.b.WriteString("  <" + link("loc"))
+		w.attr(xlink("type"), "locator")
+		w.attr(xlink("href"), href)
+		w.attr(xlink("label"), loc.Label)
+		w.b.WriteString("/>\n")
+	}
+	for _, fn := range fl.Footnotes {
+		w.b.WriteString("  <" + link("footnote"))
+		w.attr(xlink("type"), "resource")
+		w.attr(xlink("label"), fn.Label)
+		w.attr(xlink("role"), fn.Role)
+		w.attr("xml:lang", fn.Lang)
+		w.attr("id", fn.ID)
+		// 脚注の内容（XHTML）の要素は XHTML の名前空間に置く
+		if strings.Contains(fn.Value, "<") {
+			w.attr("xmlns", nsXHTML)
+		}
+		w.b.WriteString(">" + fn.Value + "</" + link("footnote") + ">\n")
+	}
+	for _, arc := range fl.Arcs {
+		w.b.WriteString("  <" + link("footnoteArc"))
+		w.attr(xlink("type"), "arc")
+		w.attr(xlink("arcrole"), arc.ArcRole)
+		w.attr(xlink("from"), arc.From)
+		w.attr(xlink("to"), arc.To)
+		w.attr("order", arc.Order)
+		w.b.WriteString("/>\n")
+	}
+	w.b.WriteString(" </" + link("footnoteLink") + ">\n")
+}
+
+// 元のインスタンスからの相対パスを、出力先のファイルからの相対パスにする
+// 出力先が無い場合、URL の場合、ZIPファイル内を指す場合、パスを求められない場合はそのまま返す
+func relocateHref(instancePath, href, outputFile string) string {
+	if outputFile == "" || instancePath == "" || href == "" || parser.IsRemoteFile(href) {
+		return href
+	}
+	resolved := parser.ResolveHref(instancePath, href)
+	if parser.IsRemoteFile(resolved) {
+		return resolved
+	}
+	// ZIPファイル内のエントリはディスク上のパスでは指せないので、元の相対パスのままにする
+	if parser.InArchive(resolved) {
+		return href
+	}
+
+	target, err := filepath.Abs(resolved)
+	if err != nil {
+		return href
+	}
+	dir, err := filepath.Abs(filepath.Dir(outputFile))
+	if err != nil {
+		return href
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return href
+	}
+	return filepath.ToSlash(rel)
+}
+
+// 2つのインスタンスのファクトが同じ集合かを確かめる
+// 要素名・コンテキスト・単位・精度・nil・言語・値の組を比べる
+func CompareFacts(expected, actual *model.XBRLInstance) error {
+	key := func(fact *model.Fact) string {
+		isNil := fact.Nil == "true" || fact.Nil == "1"
+		decimals := strings.TrimSpace(fact.Decimals)
+		if isNil {
+			decimals = ""
+		}
+		return strings.Join([]string{
+			"{" + fact.XMLName.Space + "}" + fact.XMLName.Local,
+			fact.ContextRef,
+			fact.UnitRef,
+			decimals,
+			fmt.Sprint(isNil),
+			fact.Lang,
+			fact.Value,
+		}, "\x00")
+	}
+
+	counts := make(map[string]int)
+	for i := range expected.Facts {
+		counts[key(&expected.Facts[i])]++
+	}
+	var extra []string
+	for i := range actual.Facts {
+		k := key(&actual.Facts[i])
+		if counts[k] == 0 {
+			extra = append(extra, actual.Facts[i].XMLName.Local)
+			continue
+		}
+		counts[k]--
+	}
+	var missing []string
+	for i := range expected.Facts {
+		k := key(&expected.Facts[i])
+		if counts[k] > 0 {
+			counts[k]--
+			missing = append(missing, expected.Facts[i].XMLName.Local)
+		}
+	}
+
+	if len(missing) > 0 || len(extra) > 0 {
+		return fmt.Errorf("fact set differs: %d missing %v, %d unexpected %v", len(missing), missing, len(extra), extra)
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"thermal/model"
+	"thermal/parser"
+	"thermal/resolver"
+	"time"
+)
+
+// OIM（Open Information Model）で使う名前空間
+const (
+	nsOIM       = "https://xbrl.org/2021"
+	nsXBRLI     = "http://www.xbrl.org/2003/instance"
+	nsISO4217   = "http://www.xbrl.org/2003/iso4217"
+	roleLink    = "http://www.xbrl.org/2003/role/link"
+	linkGroup   = "_" // 標準の拡張リンクロールのリンクグループ名
+	noteConcept = "xbrl:note"
+)
+
+// xBRL-JSON と xBRL-CSV で共通の、インスタンスをOIMの表現にする処理
+type oimReport struct {
+	instance   *model.XBRLInstance
+	namespaces map[string]string // プレフィックス → 名前空間URI
+	prefixes   map[string]string // 名前空間URI → プレフィックス
+	contexts   map[string]*model.Context
+	units      map[string]*model.Unit
+	factIDs    map[*model.Fact]string
+}
+
+func newOIMReport(instance *model.XBRLInstance) *oimReport {
+	r := &oimReport{
+		instance:   instance,
+		namespaces: make(map[string]string),
+		prefixes:   make(map[string]string),
+		contexts:   make(map[string]*model.Context, len(instance.Contexts)),
+		units:      make(map[string]*model.Unit, len(instance.Units)),
+		factIDs:    make(map[*model.Fact]string, len(instance.Facts)),
+	}
+
+	r.addNamespace("xbrl", nsOIM)
+	r.addNamespace("xbrli", nsXBRLI)
+	r.addNamespace("iso4217", nsISO4217)
+	// インスタンスで宣言されたプレフィックスは、そのまま使う（デフォルト名前空間は除く）
+	declared := make([]string, 0, len(instance.Namespaces))
+	for prefix := range instance.Namespaces {
+		if prefix != "(default)" {
+			declared = append(declared, prefix)
+		}
+	}
+	sort.Strings(declared)
+	for _, prefix := range declared {
+		r.addNamespace(prefix, instance.Namespaces[prefix])
+	}
+
+	for i := range instance.Contexts {
+		r.contexts[instance.Contexts[i].ID] = &instance.Contexts[i]
+	}
+	for i := range instance.Units {
+		r.units[instance.Units[i].ID] = &instance.Units[i]
+	}
+
+	// ID の無いファクトには、既存の ID と重ならない ID を振る
+	used := make(map[string]bool)
+	for i := range instance.Facts {
+		if instance.Facts[i].ID != "" {
+			used[instance.Facts[i].ID] = true
+		}
+	}
+	n := 0
+	for i := range instance.Facts {
+		fact := &instance.Facts[i]
+		id := fact.ID
+		for id == "" || (fact.ID == "" && used[id]) {
+			n++
+			id = fmt.Sprintf("f%d", n)
+		}
+		used[id] = true
+		r.factIDs[fact] = id
+	}
+	return r
+}
+
+// 名前空間を登録する。プレフィックスが別の名前空間で使われていれば別名にする
+func (r *oimReport) addNamespace(prefix, uri string) string {
+	if p, ok := r.prefixes[uri]; ok {
+		return p
+	}
+	candidate := prefix
+	for i := 1; ; i++ {
+		if _, ok := r.namespaces[candidate]; !ok {
+			break
+		}
+		candidate = fmt.Sprintf("%s%d", prefix, i)
+	}
+	r.namespaces[candidate] = uri
+	r.prefixes[uri] = candidate
+	return candidate
+}
+
+// 名前空間付きの名前を、プレフィックス付きの QName にする
+func (r *oimReport) qname(name xml.Name) string {
+	prefix, ok := r.prefixes[name.Space]
+	if !ok {
+		prefix = r.addNamespace("ns", name.Space)
+	}
+	return prefix + ":" + name.Local
+}
+
+// インスタンスに書かれた QName を、レポートのプレフィックスで書き直す
+func (r *oimReport) resolveQName(qname string) string {
+	return r.qname(parser.ResolveXMLName(strings.TrimSpace(qname), r.instance.Namespaces))
+}
+
+// entity コア・ディメンション（スキームのプレフィックス:識別子）
+func (r *oimReport) entity(context *model.Context) string {
+	identifier := context.Entity.Identifier
+	prefix, ok := r.prefixes[strings.TrimSpace(identifier.Scheme)]
+	if !ok {
+		prefix = r.addNamespace("scheme", strings.TrimSpace(identifier.Scheme))
+	}
+	return prefix + ":" + strings.TrimSpace(identifier.Value)
+}
+
+// period コア・ディメンション。日付だけの終了日・時点はその日の終わり（翌日 0 時）とする
+// forever の期間は空文字を返す（period を付けない）
+func oimPeriod(period model.Period) string {
+	if instant := strings.TrimSpace(period.Instant); instant != "" {
+		return oimDateTime(instant, true)
+	}
+	start, end := strings.TrimSpace(period.StartDate), strings.TrimSpace(period.EndDate)
+	if start == "" && end == "" {
+		return ""
+	}
+	return oimDateTime(start, false) + "/" + oimDateTime(end, true)
+}
+
+func oimDateTime(value string, endOfDay bool) string {
+	if strings.Contains(value, "T") {
+		return value
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return value
+	}
+	if endOfDay {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date.Format("2006-01-02T15:04:05")
+}
+
+// unit コア・ディメンション。xbrli:pure だけの単位は付けない
+func (r *oimReport) unit(unitRef string) string {
+	unit, ok := r.units[unitRef]
+	if !ok {
+		return ""
+	}
+	format := func(names []xml.Name) string {
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = r.qname(name)
+		}
+		sort.Strings(parts)
+		s := strings.Join(parts, "*")
+		if len(parts) > 1 {
+			s = "(" + s + ")"
+		}
+		return s
+	}
+
+	if len(unit.Denominator) == 0 {
+		if len(unit.Numerator) == 1 && unit.Numerator[0] == (xml.Name{Space: nsXBRLI, Local: "pure"}) {
+			return ""
+		}
+		return strings.Trim(format(unit.Numerator), "()")
+	}
+	return format(unit.Numerator) + "/" + format(unit.Denominator)
+}
+
+// decimals 属性を数値にする。INF 又は指定なしは nil
+func oimDecimals(decimals string) *int {
+	d, err := strconv.Atoi(strings.TrimSpace(decimals))
+	if err != nil {
+		return nil
+	}
+	return &d
+}
+
+// ファクトのコア・ディメンションとタクソノミ定義ディメンション
+func (r *oimReport) dimensions(fact *model.Fact) (map[string]string, error) {
+	dims := map[string]string{
+		"concept": r.qname(fact.XMLName),
+	}
+
+	context, ok := r.contexts[fact.ContextRef]
+	if !ok {
+		return nil, fmt.Errorf("context not found: %s (fact %s)", fact.ContextRef, fact.XMLName.Local)
+	}
+	dims["entity"] = r.entity(context)
+	if period := oimPeriod(context.Period); period != "" {
+		dims["period"] = period
+	}
+	if fact.UnitRef != "" {
+		if unit := r.unit(fact.UnitRef); unit != "" {
+			dims["unit"] = unit
+		}
+	}
+	// 非数値のファクトは xml:lang を language コア・ディメンションにする
+	if lang := strings.TrimSpace(fact.Lang); fact.UnitRef == "" && lang != "" {
+		dims["language"] = lang
+	}
+
+	for _, dim := range context.Dimensions() {
+		if dim.Typed {
+			dims[r.resolveQName(dim.Dimension)] = typedMemberValue(dim.Member)
+		} else {
+			dims[r.resolveQName(dim.Dimension)] = r.resolveQName(dim.Member)
+		}
+	}
+	return dims, nil
+}
+
+// ファクトの値。nil のときは nil、数値はそのままの字句（前後の空白を除く）
+func oimValue(fact *model.Fact) *string {
+	if fact.Nil == "true" || fact.Nil == "1" {
+		return nil
+	}
+	value := fact.Value
+	if fact.UnitRef != "" {
+		value = strings.TrimSpace(value)
+	}
+	return &value
+}
+
+// 型付きメンバーの子要素の内容（文字列）を取り出す
+func typedMemberValue(content string) string {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	var text strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// 脚注をOIMの注記ファクトとリンクにする
+type oimNote struct {
+	id       string
+	footnote *model.Footnote
+}
+
+type oimLinks struct {
+	notes      []oimNote
+	links      map[*model.Fact]map[string]map[string][]string // ファクト → リンク種別 → リンクグループ → 対象ID
+	linkTypes  map[string]string                              // リンク種別 → アークロール
+	linkGroups map[string]string                              // リンクグループ → 拡張リンクロール
+}
+
+// 標準のアークロールに対応するリンク種別名
+var oimLinkTypes = map[string]string{
+	"http://www.xbrl.org/2003/arcrole/fact-footnote":        "footnote",
+	"http://www.xbrl.org/2009/arcrole/fact-explanatoryFact": "explanatoryFact",
+}
+
+// 脚注リンクの関係を、リンク種別（アークロールの末尾）とリンクグループごとにまとめる
+func (r *oimReport) footnoteLinks() (*oimLinks, error) {
+	grouped, err := resolver.TraverseFootnoteLink(r.instance)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &oimLinks{
+		links:      make(map[*model.Fact]map[string]map[string][]string),
+		linkTypes:  make(map[string]string),
+		linkGroups: make(map[string]string),
+	}
+	noteIDs := make(map[*model.Footnote]string)
+	used := make(map[string]bool)
+	for _, id := range r.factIDs {
+		used[id] = true
+	}
+
+	groupNames := make(map[string]string)
+	var roles []string
+	for role := range grouped {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		group, ok := groupNames[role]
+		if !ok {
+			group = linkGroup
+			if role != roleLink {
+				group = fmt.Sprintf("g%d", len(groupNames)+1)
+			}
+			groupNames[role] = group
+			result.linkGroups[group] = role
+		}
+
+		for _, rel := range grouped[role] {
+			from := rel.From.(*model.Fact)
+			arcRole := rel.Arc.(*model.FootnoteArc).ArcRole
+			linkType, ok := oimLinkTypes[arcRole]
+			if !ok {
+				linkType = arcRole[strings.LastIndex(arcRole, "/")+1:]
+			}
+			result.linkTypes[linkType] = arcRole
+
+			var target string
+			switch to := rel.To.(type) {
+			case *model.Footnote:
+				id, ok := noteIDs[to]
+				if !ok {
+					id = to.ID
+					for n := len(noteIDs) + 1; id == "" || used[id]; n++ {
+						id = fmt.Sprintf("fn%d", n)
+					}
+					used[id] = true
+					noteIDs[to] = id
+					result.notes = append(result.notes, oimNote{id: id, footnote: to})
+				}
+				target = id
+			case *model.Fact:
+				target = r.factIDs[to]
+			default:
+				continue
+			}
+
+			if result.links[from] == nil {
+				result.links[from] = make(map[string]map[string][]string)
+			}
+			if result.links[from][linkType] == nil {
+				result.links[from][linkType] = make(map[string][]string)
+			}
+			result.links[from][linkType][group] = append(result.links[from][linkType][group], target)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,443 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"thermal/model"
+	"thermal/resolver"
+)
+
+// xBRL-CSV の文書種別
+const xbrlCSVDocumentType = "https://xbrl.org/2021/xbrl-csv"
+
+// xBRL-CSV のメタデータファイル名
+const XBRLCSVMetadataFile = "metadata.json"
+
+// 🔖 xBRL-CSV のメタデータ
+type XBRLCSVMetadata struct {
+	DocumentInfo   XBRLCSVDocumentInfo        `json:"documentInfo"`
+	TableTemplates map[string]XBRLCSVTemplate `json:"tableTemplates"`
+	Tables         map[string]XBRLCSVTable    `json:"tables"`
+}
+
+type XBRLCSVDocumentInfo struct {
+	DocumentType string            `json:"documentType"`
+	Namespaces   map[string]string `json:"namespaces"`
+	Taxonomy     []string          `json:"taxonomy"`
+}
+
+// 🔖 表テンプレート。dimensions の "$列名" は行ごとの列の値を参照する
+type XBRLCSVTemplate struct {
+	Dimensions map[string]string        `json:"dimensions,omitempty"`
+	Columns    map[string]XBRLCSVColumn `json:"columns"`
+}
+
+// 🔖 列定義。dimensions の無い列は他の列から参照される値の列
+type XBRLCSVColumn struct {
+	Dimensions map[string]string `json:"dimensions,omitempty"`
+	Decimals   string            `json:"decimals,omitempty"`
+}
+
+type XBRLCSVTable struct {
+	Template string `json:"template"`
+	URL      string `json:"url"`
+}
+
+// xBRL-CSV のレポート（メタデータと、ファイル名ごとの表）
+type XBRLCSVReport struct {
+	Metadata XBRLCSVMetadata
+	Tables   map[string][][]string // ファイル名 → ヘッダー行を含む行
+}
+
+// 予約された列（コア・ディメンション）
+const (
+	csvColEntity = "entity"
+	csvColPeriod = "period"
+	csvColUnit   = "unit"
+)
+
+// 表1つ分（ELRごと）の組み立て途中の状態
+type csvTable struct {
+	name     string
+	concepts []string // 列にする要素の QName（表示リンクの順）
+	facts    []*model.Fact
+}
+
+// インスタンスを xBRL-CSV（OIM）のレポートにする
+// ファクトは表示リンクのELRごとの表に振り分け、どのELRにも無い要素のファクトは facts 表に入れる
+// 行は entity・period・unit・ディメンションの組合せ、列は要素とする
+func BuildXBRLCSV(instance *model.XBRLInstance) (*XBRLCSVReport, error) {
+	r := newOIMReport(instance)
+
+	tables, err := csvTables(instance.SchemaRefs.Schema, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &XBRLCSVReport{
+		Metadata: XBRLCSVMetadata{
+			DocumentInfo: XBRLCSVDocumentInfo{
+				DocumentType: xbrlCSVDocumentType,
+				Taxonomy:     []string{instance.SchemaRefs.Href},
+			},
+			TableTemplates: make(map[string]XBRLCSVTemplate),
+			Tables:         make(map[string]XBRLCSVTable),
+		},
+		Tables: make(map[string][][]string),
+	}
+
+	for _, table := range tables {
+		template, rows, err := table.build(r)
+		if err != nil {
+			return nil, err
+		}
+		file := table.name + ".csv"
+		report.Metadata.TableTemplates[table.name] = template
+		report.Metadata.Tables[table.name] = XBRLCSVTable{Template: table.name, URL: file}
+		report.Tables[file] = rows
+	}
+
+	// 名前空間はファクトの変換中にも追加されるので最後に設定する
+	report.Metadata.DocumentInfo.Namespaces = r.namespaces
+	return report, nil
+}
+
+// ファクトを表示リンクのELRごとの表に振り分ける。要素は最初に現れたELRの表に入れる
+func csvTables(schema *model.XBRLSchema, r *oimReport) ([]*csvTable, error) {
+	grouped, err := resolver.TraversePresentationLink(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make(map[xml.Name]*model.XMLElement)
+	resolver.CollectElementsByName(schema, elements)
+	names := make(map[*model.XMLElement]xml.Name, len(elements))
+	for name, elem := range elements {
+		names[elem] = name
+	}
+
+	roleTypes := make(map[string]*model.RoleType)
+	resolver.CollectRoleTypesByHref(schema, roleTypes)
+	roleIDs := make(map[string]string)
+	for _, rt := range roleTypes {
+		roleIDs[rt.RoleURI] = rt.Id
+	}
+
+	var tables []*csvTable
+	tableNames := make(map[string]bool)
+	newTable := func(name string) *csvTable {
+		name = csvIdentifier(name)
+		base := name
+		for i := 2; tableNames[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		tableNames[name] = true
+		t := &csvTable{name: name}
+		tables = append(tables, t)
+		return t
+	}
+
+	tableOf := make(map[string]*csvTable)
+	for _, role := range sortedRoles(grouped) {
+		var table *csvTable
+		for _, elem := range presentationOrder(grouped[role]) {
+			name, ok := names[elem]
+			if !ok {
+				continue
+			}
+			concept := r.qname(name)
+			if _, ok := tableOf[concept]; ok {
+				continue
+			}
+			if table == nil {
+				id := roleIDs[role]
+				if id == "" {
+					id = role[strings.LastIndex(role, "/")+1:]
+				}
+				table = newTable(id)
+			}
+			tableOf[concept] = table
+			table.concepts = append(table.concepts, concept)
+		}
+	}
+
+	var others *csvTable
+	for i := range r.instance.Facts {
+		fact := &r.instance.Facts[i]
+		concept := r.qname(fact.XMLName)
+		table, ok := tableOf[concept]
+		if !ok {
+			if others == nil {
+				others = newTable("facts")
+			}
+			table = others
+			tableOf[concept] = table
+			table.concepts = append(table.concepts, concept)
+		}
+		table.facts = append(table.facts, fact)
+	}
+
+	// ファクトの無い表は出力しない
+	var result []*csvTable
+	for _, table := range tables {
+		if len(table.facts) > 0 {
+			result = append(result, table)
+		}
+	}
+	return result, nil
+}
+
+// 表示リンクの要素を、ルートから order 順にたどった並びにする
+func presentationOrder(relations []resolver.ArcRelation) []*model.XMLElement {
+	adj := resolver.BuildAdjacency(relations)
+	var roots []*model.XMLElement
+	for _, root := range resolver.FindRootNodes(relations) {
+		if elem, ok := root.(*model.XMLElement); ok {
+			roots = append(roots, elem)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Name < roots[j].Name })
+
+	var result []*model.XMLElement
+	visited := make(map[*model.XMLElement]bool)
+	var walk func(elem *model.XMLElement)
+	walk = func(elem *model.XMLElement) {
+		if visited[elem] {
+			return
+		}
+		visited[elem] = true
+		result = append(result, elem)
+
+		children := append([]*resolver.ArcRelation{}, adj[elem]...)
+		sort.SliceStable(children, func(i, j int) bool {
+			return resolver.ArcOrder(children[i]) < resolver.ArcOrder(children[j])
+		})
+		for _, child := range children {
+			if to, ok := child.To.(*model.XMLElement); ok {
+				walk(to)
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	return result
+}
+
+// 表のテンプレートと、ヘッダー行を含む行を作る
+// 数値の要素の列は unit 列と、要素ごとの decimals 列を参照する
+func (t *csvTable) build(r *oimReport) (XBRLCSVTemplate, [][]string, error) {
+
+	// ファクトのディメンションと、列にする要素・ディメンションを集める
+	factDims := make(map[*model.Fact]map[string]string, len(t.facts))
+	used := make(map[string]bool)
+	numeric := make(map[string]bool)
+	var dimensions []string
+	for _, fact := range t.facts {
+		dims, err := r.dimensions(fact)
+		if err != nil {
+			return XBRLCSVTemplate{}, nil, err
+		}
+		factDims[fact] = dims
+		used[dims["concept"]] = true
+		if fact.UnitRef != "" {
+			numeric[dims["concept"]] = true
+		}
+		for name := range dims {
+			switch name {
+			case "concept", "entity", "period", "unit":
+				continue
+			}
+			if !slices.Contains(dimensions, name) {
+				dimensions = append(dimensions, name)
+			}
+		}
+	}
+	sort.Strings(dimensions)
+
+	// 列名はプレフィックスとローカル名から作る
+	columnNames := map[string]bool{csvColEntity: true, csvColPeriod: true, csvColUnit: true}
+	columnName := func(name string) string {
+		name = csvIdentifier(strings.Replace(name, ":", "_", 1))
+		base := name
+		for i := 2; columnNames[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		columnNames[name] = true
+		return name
+	}
+
+	template := XBRLCSVTemplate{
+		Dimensions: map[string]string{
+			"entity": "$" + csvColEntity,
+			"period": "$" + csvColPeriod,
+		},
+		Columns: map[string]XBRLCSVColumn{
+			csvColEntity: {},
+			csvColPeriod: {},
+			csvColUnit:   {},
+		},
+	}
+	header := []string{csvColEntity, csvColPeriod, csvColUnit}
+
+	dimColumns := make(map[string]int, len(dimensions))
+	for _, dim := range dimensions {
+		col := columnName(dim)
+		template.Dimensions[dim] = "$" + col
+		template.Columns[col] = XBRLCSVColumn{}
+		dimColumns[dim] = len(header)
+		header = append(header, col)
+	}
+
+	valueColumns := make(map[string]int)
+	decimalsColumns := make(map[string]int)
+	for _, concept := range t.concepts {
+		if !used[concept] {
+			continue
+		}
+		col := columnName(concept)
+		column := XBRLCSVColumn{Dimensions: map[string]string{"concept": concept}}
+		valueColumns[concept] = len(header)
+		header = append(header, col)
+		if numeric[concept] {
+			decimals := columnName(concept + "_decimals")
+			column.Dimensions["unit"] = "$" + csvColUnit
+			column.Decimals = "$" + decimals
+			template.Columns[decimals] = XBRLCSVColumn{}
+			decimalsColumns[concept] = len(header)
+			header = append(header, decimals)
+		}
+		template.Columns[col] = column
+	}
+
+	// entity・period・unit・ディメンションが同じファクトを同じ行にまとめる
+	// 同じセルに2つ目のファクト（重複ファクト）が来たときは、同じキーの行を追加する
+	rows := [][]string{header}
+	rowsByKey := make(map[string][]int)
+	for _, fact := range t.facts {
+		dims := factDims[fact]
+		key := make([]string, len(header))
+		key[0], key[1], key[2] = dims["entity"], dims["period"], dims["unit"]
+		for dim, i := range dimColumns {
+			key[i] = dims[dim]
+		}
+		keyString := strings.Join(key, "\x00")
+
+		col := valueColumns[dims["concept"]]
+		row := -1
+		for _, i := range rowsByKey[keyString] {
+			if rows[i][col] == "" {
+				row = i
+				break
+			}
+		}
+		if row < 0 {
+			rows = append(rows, key)
+			row = len(rows) - 1
+			rowsByKey[keyString] = append(rowsByKey[keyString], row)
+		}
+
+		rows[row][col] = csvValue(fact)
+		if i, ok := decimalsColumns[dims["concept"]]; ok {
+			if d := oimDecimals(fact.Decimals); d != nil {
+				rows[row][i] = strconv.Itoa(*d)
+			}
+		}
+	}
+	return template, rows, nil
+}
+
+// セルの値。nil は #nil、空文字は #empty、# で始まる値は # を重ねる
+func csvValue(fact *model.Fact) string {
+	value := oimValue(fact)
+	switch {
+	case value == nil:
+		return "#nil"
+	case *value == "":
+		return "#empty"
+	case strings.HasPrefix(*value, "#"):
+		return "#" + *value
+	}
+	return *value
+}
+
+var csvIdentifierInvalid = regexp.MustCompile(`[^A-Za-z0-9_\-]`)
+
+// 表名・列名に使える識別子にする
+func csvIdentifier(name string) string {
+	name = csvIdentifierInvalid.ReplaceAllString(name, "_")
+	if name == "" || !(name[0] == '_' || (name[0] >= 'A' && name[0] <= 'Z') || (name[0] >= 'a' && name[0] <= 'z')) {
+		name = "_" + name
+	}
+	return name
+}
+
+// xBRL-CSV をファイル名ごとの文字列にする（メタデータと各表）
+func XBRLCSV(instance *model.XBRLInstance) (map[string]string, error) {
+	report, err := BuildXBRLCSV(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string, len(report.Tables)+1)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report.Metadata); err != nil {
+		return nil, err
+	}
+	files[XBRLCSVMetadataFile] = buf.String()
+
+	for file, rows := range report.Tables {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+		files[file] = buf.String()
+	}
+	return files, nil
+}
+
+// xBRL-CSV のレポートをレポートパッケージにする（ZIP内のパス → 内容）
+// ファイル名（拡張子を除く）のディレクトリの下に META-INF/reportPackage.json と reports/ を置く
+func XBRLCSVReportPackage(instance *model.XBRLInstance, filename string) (map[string]string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	// xBRL-CSV は Inline XBRL ではないので .xbri にはできない
+	documentType, ok := model.ReportPackageDocumentTypes[ext]
+	if !ok || ext == ".xbri" {
+		return nil, fmt.Errorf("xbrl-csv report package must be a .xbr or .zip file: %s", filename)
+	}
+
+	files, err := XBRLCSV(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var info model.ReportPackageJSON
+	info.DocumentInfo.DocumentType = documentType
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	root := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	entries := make(map[string]string, len(files)+1)
+	entries[root+"/META-INF/reportPackage.json"] = string(b) + "\n"
+	for name, content := range files {
+		entries[root+"/reports/"+name] = content
+	}
+	return entries, nil
+}
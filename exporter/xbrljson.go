@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"thermal/model"
+)
+
+// xBRL-JSON の文書種別
+const xbrlJSONDocumentType = "https://xbrl.org/2021/xbrl-json"
+
+// 🔖 xBRL-JSON レポート
+type XBRLJSONReport struct {
+	DocumentInfo XBRLJSONDocumentInfo    `json:"documentInfo"`
+	Facts        map[string]XBRLJSONFact `json:"facts"`
+}
+
+type XBRLJSONDocumentInfo struct {
+	DocumentType string            `json:"documentType"`
+	Namespaces   map[string]string `json:"namespaces"`
+	LinkTypes    map[string]string `json:"linkTypes,omitempty"`
+	LinkGroups   map[string]string `json:"linkGroups,omitempty"`
+	Taxonomy     []string          `json:"taxonomy"`
+}
+
+// 🔖 xBRL-JSON のファクト（value が nil なら xsi:nil）
+type XBRLJSONFact struct {
+	Value      *string                        `json:"value"`
+	Decimals   *int                           `json:"decimals,omitempty"`
+	Dimensions map[string]string              `json:"dimensions"`
+	Links      map[string]map[string][]string `json:"links,omitempty"`
+}
+
+// インスタンスを xBRL-JSON（OIM）のレポートにする
+// 脚注は xbrl:note の注記ファクトとし、ファクトからリンクで参照する
+func BuildXBRLJSON(instance *model.XBRLInstance) (*XBRLJSONReport, error) {
+	r := newOIMReport(instance)
+
+	report := &XBRLJSONReport{
+		DocumentInfo: XBRLJSONDocumentInfo{
+			DocumentType: xbrlJSONDocumentType,
+			Taxonomy:     []string{instance.SchemaRefs.Href},
+		},
+		Facts: make(map[string]XBRLJSONFact, len(instance.Facts)),
+	}
+
+	links, err := r.footnoteLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range instance.Facts {
+		fact := &instance.Facts[i]
+		dims, err := r.dimensions(fact)
+		if err != nil {
+			return nil, err
+		}
+		out := XBRLJSONFact{
+			Value:      oimValue(fact),
+			Dimensions: dims,
+			Links:      links.links[fact],
+		}
+		if fact.UnitRef != "" {
+			out.Decimals = oimDecimals(fact.Decimals)
+		}
+		report.Facts[r.factIDs[fact]] = out
+	}
+
+	for _, note := range links.notes {
+		value := note.footnote.Value
+		dims := map[string]string{
+			"concept": noteConcept,
+			"noteId":  note.id,
+		}
+		if note.footnote.Lang != "" {
+			dims["language"] = note.footnote.Lang
+		}
+		report.Facts[note.id] = XBRLJSONFact{Value: &value, Dimensions: dims}
+	}
+
+	if len(links.linkTypes) > 0 {
+		report.DocumentInfo.LinkTypes = links.linkTypes
+		report.DocumentInfo.LinkGroups = links.linkGroups
+	}
+	// 名前空間はファクトの変換で追加されるので最後に設定する
+	report.DocumentInfo.Namespaces = r.namespaces
+	return report, nil
+}
+
+// xBRL-JSON 形式文字列作成
+func XBRLJSON(instance *model.XBRLInstance) (string, error) {
+	report, err := BuildXBRLJSON(instance)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // 脚注のXHTMLをそのまま出す
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
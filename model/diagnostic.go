@@ -0,0 +1,30 @@
+package model
+
+// 診断情報の重要度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// 診断情報のコード
+const (
+	DiagLinkbaseParse = "linkbase-parse" // リンクベースを読み込めない
+	DiagSchemaImport  = "schema-import"  // インポートしたスキーマを読み込めない
+	DiagInvalidArc    = "invalid-arc"    // アークの from・to に対応するロケータ又はリソースが無い
+	DiagInvalidLoc    = "invalid-loc"    // ロケータの指す要素が無い
+	DiagTransform     = "transform"      // ix:format の変換に失敗した
+	DiagStaleCache    = "stale-cache"    // リモートのファイルを取得できず、古いキャッシュを使った
+	DiagCacheWrite    = "cache-write"    // 取得したファイルをキャッシュに書き込めない
+)
+
+// 🔖 DTSの読込み・関係の解決で見つかった問題1件分
+type Diagnostic struct {
+	Severity Severity `yaml:"Severity"`
+	Code     string   `yaml:"Code"`
+	File     string   `yaml:"File"`
+	Element  string   `yaml:"Element"`
+	Message  string   `yaml:"Message"`
+}
@@ -2,18 +2,23 @@ package model
 
 import (
 	"encoding/xml"
+	"slices"
+	"sort"
+	"strings"
 )
 
 // XBRLインスタンスのトップレベル構造
 type XBRLInstance struct {
-	Path         string         // インスタンスファイル名
-	XMLName      xml.Name       `xml:"xbrl"`
-	SchemaRefs   SchemaRef      `xml:"schemaRef"`
-	RoleRefs     []RoleRef      `xml:"roleRef"`
-	Contexts     []Context      `xml:"context"`
-	Units        []Unit         `xml:"unit"`
-	Facts        []Fact         `xml:",any"`
-	FootnoteLink []FootnoteLink `xml:"footnoteLink"`
+	Path         string            // インスタンスファイル名
+	XMLName      xml.Name          `xml:"xbrl"`
+	SchemaRefs   SchemaRef         `xml:"schemaRef"`
+	RoleRefs     []RoleRef         `xml:"roleRef"`
+	Contexts     []Context         `xml:"context"`
+	Units        []Unit            `xml:"unit"`
+	Facts        []Fact            `xml:",any"`
+	FootnoteLink []FootnoteLink    `xml:"footnoteLink"`
+	Attrs        []xml.Attr        `xml:",any,attr"`
+	Namespaces   map[string]string `xml:"-"` // プレフィックス → 名前空間URI（デフォルト名前空間は "(default)"）
 }
 
 // スキーマ定義
@@ -39,6 +44,7 @@ type Context struct {
 // 企業識別情報
 type Entity struct {
 	Identifier Identifier `xml:"identifier"`
+	Segment    Segment    `xml:"segment"`
 }
 
 type Identifier struct {
@@ -53,32 +59,157 @@ type Period struct {
 	Instant   string `xml:"instant"`
 }
 
+// セグメント情報
+type Segment struct {
+	Members      []Member      `xml:"explicitMember"`
+	TypedMembers []TypedMember `xml:"typedMember"`
+}
+
 // シナリオ情報（セグメントや補足情報）
 type Scenario struct {
-	Members []Member `xml:"explicitMember"`
+	Members      []Member      `xml:"explicitMember"`
+	TypedMembers []TypedMember `xml:"typedMember"`
 }
 
+// 明示的メンバー
 type Member struct {
 	Dimension string `xml:"dimension,attr"`
 	Value     string `xml:",chardata"`
 }
 
+// 型付きメンバー（値は子要素のXML）
+type TypedMember struct {
+	Dimension string `xml:"dimension,attr"`
+	Value     string `xml:",innerxml"`
+}
+
+// コンテキストのディメンション（segment, scenario のどちらに置かれたかを含む）
+type ContextDimension struct {
+	Dimension      string
+	Member         string
+	Typed          bool
+	ContextElement string // segment 又は scenario
+}
+
+// segment と scenario の全ディメンションを返す
+func (c *Context) Dimensions() []ContextDimension {
+	var dims []ContextDimension
+	add := func(element string, members []Member, typedMembers []TypedMember) {
+		for _, m := range members {
+			dims = append(dims, ContextDimension{
+				Dimension:      strings.TrimSpace(m.Dimension),
+				Member:         strings.TrimSpace(m.Value),
+				ContextElement: element,
+			})
+		}
+		for _, m := range typedMembers {
+			dims = append(dims, ContextDimension{
+				Dimension:      strings.TrimSpace(m.Dimension),
+				Member:         strings.TrimSpace(m.Value),
+				Typed:          true,
+				ContextElement: element,
+			})
+		}
+	}
+	add("segment", c.Entity.Segment.Members, c.Entity.Segment.TypedMembers)
+	add("scenario", c.Scenario.Members, c.Scenario.TypedMembers)
+	return dims
+}
+
 // 単位情報
 type Unit struct {
-	ID      string `xml:"id,attr"`
-	Measure string `xml:"measure"`
+	ID          string     `xml:"id,attr"`
+	Measures    []string   `xml:"measure"` // 単純な単位又は乗算
+	Divide      UnitDivide `xml:"divide"`  // 除算
+	Numerator   []xml.Name `xml:"-"`       // 名前空間を解決した分子（除算でない場合は全ての measure）
+	Denominator []xml.Name `xml:"-"`       // 名前空間を解決した分母
+}
+
+type UnitDivide struct {
+	Numerator   []string `xml:"unitNumerator>measure"`
+	Denominator []string `xml:"unitDenominator>measure"`
+}
+
+// 記述どおりの単位表記（例: iso4217:JPY/xbrli:shares）
+func (u *Unit) Measure() string {
+	if len(u.Divide.Numerator) > 0 {
+		return joinMeasures(u.Divide.Numerator) + "/" + joinMeasures(u.Divide.Denominator)
+	}
+	return joinMeasures(u.Measures)
+}
+
+// 正規形の単位表記。measureを {名前空間}ローカル名 にして並べ替え、分子と分母で約分する
+func (u *Unit) Canonical() string {
+	num := clarkNames(u.Numerator)
+	den := clarkNames(u.Denominator)
+
+	// 分子と分母に共通する measure を取り除く
+	for i := 0; i < len(num); i++ {
+		if j := slices.Index(den, num[i]); j >= 0 {
+			num = slices.Delete(num, i, i+1)
+			den = slices.Delete(den, j, j+1)
+			i--
+		}
+	}
+	sort.Strings(num)
+	sort.Strings(den)
+
+	canonical := strings.Join(num, "*")
+	if len(den) > 0 {
+		canonical += "/" + strings.Join(den, "*")
+	}
+	return canonical
+}
+
+func joinMeasures(measures []string) string {
+	trimmed := make([]string, len(measures))
+	for i, m := range measures {
+		trimmed[i] = strings.TrimSpace(m)
+	}
+	return strings.Join(trimmed, "*")
+}
+
+func clarkNames(names []xml.Name) []string {
+	result := make([]string, len(names))
+	for i, n := range names {
+		result[i] = "{" + n.Space + "}" + n.Local
+	}
+	return result
 }
 
 // 財務データ（可変要素）
 type Fact struct {
-	XMLName    xml.Name `xml:""`
-	ContextRef string   `xml:"contextRef,attr"`
-	UnitRef    string   `xml:"unitRef,attr"`
-	Decimals   string   `xml:"decimals,attr"`
-	Nil        string   `xml:"nil,attr"`
-	Value      string   `xml:",chardata"`
+	XMLName        xml.Name `xml:""`
+	ID             string   `xml:"id,attr"`
+	ContextRef     string   `xml:"contextRef,attr"`
+	UnitRef        string   `xml:"unitRef,attr"`
+	Decimals       string   `xml:"decimals,attr"`
+	Nil            string   `xml:"nil,attr"`
+	Lang           string   `xml:"lang,attr"` // xml:lang（非数値のファクト）
+	Value          string   `xml:",chardata"`
+	TransformError string   `xml:"-"` // ix:format の変換に失敗した場合のエラー
 }
 
+// 脚注リンク
 type FootnoteLink struct {
-	Value string `xml:",chardata"`
+	Role      string        `xml:"role,attr"`
+	Locs      []Loc         `xml:"loc"`
+	Footnotes []Footnote    `xml:"footnote"`
+	Arcs      []FootnoteArc `xml:"footnoteArc"`
+}
+
+// 脚注
+type Footnote struct {
+	Label string `xml:"label,attr"`
+	ID    string `xml:"id,attr"`
+	Role  string `xml:"role,attr"`
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",innerxml"`
+}
+
+// 脚注リンクのアーク（ファクトと脚注の関係）
+type FootnoteArc struct {
+	ArcBase
+	ArcRole string `xml:"arcrole,attr"`
+	Order   string `xml:"order,attr"`
 }
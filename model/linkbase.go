@@ -12,8 +12,10 @@ type LinkBase struct {
 
 // Arc共通
 type ArcBase struct {
-	From string `xml:"from,attr"`
-	To   string `xml:"to,attr"`
+	From     string `xml:"from,attr"`
+	To       string `xml:"to,attr"`
+	Use      string `xml:"use,attr"`
+	Priority int    `xml:"priority,attr"`
 }
 
 // ロケータ
@@ -82,6 +84,7 @@ type ReferenceArc struct {
 type ReferenceReference struct {
 	Label                string `xml:"label,attr"`
 	Role                 string `xml:"role,attr"`
+	Id                   string `xml:"id,attr"`
 	Publisher            string `xml:"Publisher"`
 	Number               string `xml:"Number"`
 	Name                 string `xml:"Name"`
@@ -135,8 +138,12 @@ type DefinitionLink struct {
 // 🧩 定義リンクのアーク（要素間の関係）
 type DefinitionArc struct {
 	ArcBase
-	ArcRole string `xml:"arcrole,attr"`
-	Order   string `xml:"order,attr"`
+	ArcRole        string `xml:"arcrole,attr"`
+	Order          string `xml:"order,attr"`
+	TargetRole     string `xml:"targetRole,attr"`     // xbrldt:targetRole
+	Closed         string `xml:"closed,attr"`         // xbrldt:closed
+	ContextElement string `xml:"contextElement,attr"` // xbrldt:contextElement
+	Usable         string `xml:"usable,attr"`         // xbrldt:usable
 }
 
 // /////////////////////////////////////////////////////////////
@@ -159,8 +166,9 @@ type CalculationLink struct {
 // ➕ 計算リンクのアーク（要素間の関係）
 type CalculationArc struct {
 	ArcBase
-	ArcRole string  `xml:"arcrole,attr"`
-	Order   float64 `xml:"order,attr"`
+	ArcRole string `xml:"arcrole,attr"`
+	Order   string `xml:"order,attr"`
+	Weight  string `xml:"weight,attr"` // 計算で誤差が出ないように字句のまま持つ
 }
 
 // /////////////////////////////////////////////////////////////
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/xml"
+)
+
+// 🔖 タクソノミパッケージ（META-INF/taxonomyPackage.xml と META-INF/catalog.xml）
+type TaxonomyPackage struct {
+	Path        string          // パッケージのZIPファイル名
+	XMLName     xml.Name        `xml:"taxonomyPackage"`
+	Identifier  string          `xml:"identifier"`
+	Names       []PackageText   `xml:"name"`
+	Description []PackageText   `xml:"description"`
+	Version     string          `xml:"version"`
+	Publisher   []PackageText   `xml:"publisher"`
+	Date        string          `xml:"publicationDate"`
+	EntryPoints []EntryPoint    `xml:"entryPoints>entryPoint"`
+	Catalog     *PackageCatalog `xml:"-"`
+}
+
+// 言語ごとの文字列
+type PackageText struct {
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+// エントリーポイント
+type EntryPoint struct {
+	Names     []PackageText        `xml:"name"`
+	Documents []EntryPointDocument `xml:"entryPointDocument"`
+}
+
+type EntryPointDocument struct {
+	Href string `xml:"href,attr"`
+}
+
+// 🔖 XMLカタログ（URLの書き換え規則）
+type PackageCatalog struct {
+	XMLName       xml.Name        `xml:"catalog"`
+	RewriteURIs   []RewriteURI    `xml:"rewriteURI"`
+	RewriteSystem []RewriteSystem `xml:"rewriteSystem"`
+}
+
+type RewriteURI struct {
+	StartString   string `xml:"uriStartString,attr"`
+	RewritePrefix string `xml:"rewritePrefix,attr"`
+}
+
+type RewriteSystem struct {
+	StartString   string `xml:"systemIdStartString,attr"`
+	RewritePrefix string `xml:"rewritePrefix,attr"`
+}
+
+// 🔖 レポートパッケージの META-INF/reportPackage.json
+type ReportPackageJSON struct {
+	DocumentInfo struct {
+		DocumentType string `json:"documentType"`
+	} `json:"documentInfo"`
+}
+
+// レポートパッケージの文書種別（xbri・xbr・zip の種類ごとに末尾が異なる）
+const ReportPackageDocumentType = "https://xbrl.org/report-package/2023"
+
+// パッケージの拡張子ごとの文書種別
+var ReportPackageDocumentTypes = map[string]string{
+	".xbri": ReportPackageDocumentType + "/xbri",
+	".xbr":  ReportPackageDocumentType + "/xbr",
+	".zip":  ReportPackageDocumentType,
+}
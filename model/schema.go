@@ -30,6 +30,7 @@ type XMLElement struct {
 	Abstract          string `xml:"abstract,attr"`
 	Nillable          string `xml:"nillable,attr"`
 	PeriodType        string `xml:"periodType,attr"`
+	TypedDomainRef    string `xml:"typedDomainRef,attr"` // xbrldt:typedDomainRef
 	Schema            *XBRLSchema
 }
 
@@ -0,0 +1,352 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 出力形式
+const (
+	YAML  = "yaml"
+	JSON  = "json"
+	JSONL = "jsonl"
+	CSV   = "csv"
+	TSV   = "tsv"
+	Table = "table"
+)
+
+var Formats = []string{YAML, JSON, JSONL, CSV, TSV, Table}
+
+// -o フラグの説明
+var FormatUsage = "Output format (" + strings.Join(Formats, "|") + ")"
+
+// 出力形式の指定を確認する（空は既定の形式）
+func ValidateFormat(format string) error {
+	if format == "" || slices.Contains(Formats, format) {
+		return nil
+	}
+	return fmt.Errorf("unknown output format: %s (%s)", format, strings.Join(Formats, ", "))
+}
+
+// 値を指定の形式で書き出す
+// フィールド名は yaml タグに従うので、どの形式でも同じ名前になる
+func Write(w io.Writer, format string, v any) error {
+	if format == YAML {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2) // 読みやすさのためにインデント設定
+		return encoder.Encode(v)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return err
+	}
+	return writeNode(w, format, &node, zeroColumns(v))
+}
+
+// ヘッダーと行を指定の形式で書き出す
+// YAML と JSON ではヘッダーをキーにしたレコードの並びにする
+func WriteRows(w io.Writer, format string, header []string, rows [][]string) error {
+	if format == Table {
+		return WriteTable(w, header, rows)
+	}
+
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, row := range rows {
+		record := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for i, key := range header {
+			value := ""
+			if i < len(row) {
+				value = row[i]
+			}
+			record.Content = append(record.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+			)
+		}
+		seq.Content = append(seq.Content, record)
+	}
+
+	if format == YAML {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2) // 読みやすさのためにインデント設定
+		return encoder.Encode(seq)
+	}
+	return writeNode(w, format, seq, header)
+}
+
+func writeNode(w io.Writer, format string, node *yaml.Node, columns []string) error {
+	switch format {
+	case JSON:
+		b, err := marshalJSON(nodeValue(node), "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case JSONL:
+		for _, item := range records(node) {
+			b, err := marshalJSON(nodeValue(item), "")
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s\n", b); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV, TSV, Table:
+		header, rows := flattenRecords(records(node), columns)
+		if format == Table {
+			for _, row := range rows {
+				for i := range row {
+					row[i] = strings.ReplaceAll(row[i], "\n", " ")
+				}
+			}
+			return WriteTable(w, header, rows)
+		}
+		writer := csv.NewWriter(w)
+		if format == TSV {
+			writer.Comma = '\t'
+		}
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+	return ValidateFormat(format)
+}
+
+// 並び（スライス）なら要素ごと、それ以外は値全体を1件とする
+func records(node *yaml.Node) []*yaml.Node {
+	node = resolveNode(node)
+	switch {
+	case node.Kind == yaml.SequenceNode:
+		return node.Content
+	case node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null":
+		return nil
+	}
+	return []*yaml.Node{node}
+}
+
+func resolveNode(node *yaml.Node) *yaml.Node {
+	for {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
+
+// 表形式の列と行にする
+// 入れ子の構造体は「親.子」の列に展開し、文字列の並びは改行でつなぎ、それ以外の並びはJSONにする
+func flattenRecords(items []*yaml.Node, columns []string) ([]string, [][]string) {
+	header := append([]string{}, columns...)
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[col] = i
+	}
+
+	cells := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		cell := make(map[string]string)
+		var keys []string
+		flattenNode("", item, cell, &keys)
+		for _, key := range keys {
+			if _, ok := index[key]; !ok {
+				index[key] = len(header)
+				header = append(header, key)
+			}
+		}
+		cells = append(cells, cell)
+	}
+
+	rows := make([][]string, 0, len(cells))
+	for _, cell := range cells {
+		row := make([]string, len(header))
+		for key, value := range cell {
+			row[index[key]] = value
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func flattenNode(prefix string, node *yaml.Node, cell map[string]string, keys *[]string) {
+	node = resolveNode(node)
+	set := func(value string) {
+		key := prefix
+		if key == "" {
+			key = "Value"
+		}
+		if _, ok := cell[key]; !ok {
+			*keys = append(*keys, key)
+		}
+		cell[key] = value
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenNode(key, node.Content[i+1], cell, keys)
+		}
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range node.Content {
+			item = resolveNode(item)
+			if item.Kind != yaml.ScalarNode {
+				b, _ := marshalJSON(nodeValue(node), "")
+				set(string(b))
+				return
+			}
+			values = append(values, item.Value)
+		}
+		set(strings.Join(values, "\n"))
+	default:
+		if node.ShortTag() == "!!null" {
+			set("")
+		} else {
+			set(node.Value)
+		}
+	}
+}
+
+// 空のスライスでもヘッダーを出せるよう、要素の型のゼロ値から列名を得る
+func zeroColumns(v any) []string {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var node yaml.Node
+	if err := node.Encode(reflect.New(t).Elem().Interface()); err != nil {
+		return nil
+	}
+	cell := make(map[string]string)
+	var keys []string
+	flattenNode("", &node, cell, &keys)
+	return keys
+}
+
+// YAML のキー順を保ったままJSONにするためのマップ
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJSON(key, "")
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshalJSON(m.values[key], "")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// YAML のノードを、JSONに変換できる値にする
+func nodeValue(node *yaml.Node) any {
+	node = resolveNode(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		m := orderedMap{values: make(map[string]any, len(node.Content)/2)}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if _, ok := m.values[key]; !ok {
+				m.keys = append(m.keys, key)
+			}
+			m.values[key] = nodeValue(node.Content[i+1])
+		}
+		return m
+	case yaml.SequenceNode:
+		values := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			values = append(values, nodeValue(item))
+		}
+		return values
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil
+	case "!!bool":
+		if b, err := strconv.ParseBool(node.Value); err == nil {
+			return b
+		}
+	case "!!int":
+		if n, err := strconv.ParseInt(node.Value, 0, 64); err == nil {
+			return n
+		}
+	case "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			return f
+		}
+	}
+	return node.Value
+}
+
+// HTMLのエスケープをせずにJSONにする
+func marshalJSON(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// 改行を取り除き、先頭 limit 文字（rune単位）に切り詰める。切り詰めたときは suffix を付ける
+func SanitizeLongValue(input string, limit int, suffix string) string {
+	// 改行をすべて削除（CR, LF 両方対応）
+	noNewlines := strings.ReplaceAll(input, "\r", "")
+	noNewlines = strings.ReplaceAll(noNewlines, "\n", "")
+
+	runes := []rune(noNewlines)
+	if len(runes) > limit {
+		return string(runes[:limit]) + suffix
+	}
+	return noNewlines
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// 表示幅（全角文字は2桁）を返す
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			w += 2
+		default:
+			w++
+		}
+	}
+	return w
+}
+
+// ヘッダーと行を、列をそろえた表として書き出す
+func WriteTable(w io.Writer, header []string, rows [][]string) error {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = displayWidth(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], displayWidth(cell))
+			}
+		}
+	}
+
+	writeRow := func(row []string) error {
+		var sb strings.Builder
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(cell)
+			// 最終列は末尾の空白を付けない
+			if i < len(widths)-1 {
+				sb.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+			}
+		}
+		sb.WriteString("\n")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+
+	if err := writeRow(header); err != nil {
+		return err
+	}
+	separators := make([]string, len(widths))
+	for i, n := range widths {
+		separators[i] = strings.Repeat("-", n)
+	}
+	if err := writeRow(separators); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"thermal/model"
+)
+
+// アーカイブ内のファイルは「ZIPファイルのパス!/エントリ名」で表す
+const archiveSeparator = "!/"
+
+// 読み込んだZIPファイル（ファイル全体をメモリに読み込む。ディスクには展開しない）
+var (
+	archivesMu sync.Mutex
+	archives   = make(map[string]*zip.Reader)
+)
+
+// ZIP形式のファイルの拡張子（.xbri と .xbr はレポートパッケージ）
+var archiveExtensions = []string{".zip", ".xbri", ".xbr"}
+
+// ZIPファイルか（拡張子で判定する）
+func IsArchive(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return slices.Contains(archiveExtensions, ext)
+}
+
+// ZIPファイル内のエントリを指すパスを作る
+func ArchivePath(archive, entry string) string {
+	return archive + archiveSeparator + strings.TrimPrefix(entry, "/")
+}
+
+// アーカイブ内のパスを、ZIPファイルのパスとエントリ名に分ける
+func splitArchivePath(filename string) (string, string, bool) {
+	lower := strings.ToLower(filename)
+	for _, ext := range archiveExtensions {
+		if i := strings.Index(lower, ext+archiveSeparator); i >= 0 {
+			i += len(ext)
+			return filename[:i], filename[i+len(archiveSeparator):], true
+		}
+	}
+	return "", "", false
+}
+
+// ZIPファイル内のエントリを指すパスか
+func InArchive(filename string) bool {
+	_, _, ok := splitArchivePath(filename)
+	return ok
+}
+
+// ZIPファイルを開く。同じファイルは1度だけ読み込む
+func OpenArchive(archive string) (*zip.Reader, error) {
+	archivesMu.Lock()
+	defer archivesMu.Unlock()
+
+	if r, ok := archives[archive]; ok {
+		return r, nil
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		return nil, fmt.Errorf("❌ ZIPファイルを開けません: %s", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("❌ ZIPファイルの読み込み失敗: %s: %s", archive, err)
+	}
+	archives[archive] = r
+	return r, nil
+}
+
+// アーカイブ内のファイルを読み込む
+func readArchiveFile(filename string) ([]byte, error) {
+	archive, entry, _ := splitArchivePath(filename)
+	r, err := OpenArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	f, err := r.Open(entry)
+	if err != nil {
+		return nil, fmt.Errorf("❌ ZIPファイル内のファイルを開けません: %s", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("❌ ZIPファイル内のファイルの読み込み失敗: %s", err)
+	}
+	return data, nil
+}
+
+// アーカイブ内のパスを基準に、相対パスを解決する
+// アーカイブの外を指す場合は、ZIPファイルのあるディレクトリからのパスにする
+func resolveArchiveHref(baseFilename, href string) string {
+	archive, entry, _ := splitArchivePath(baseFilename)
+	resolved := path.Join(path.Dir(entry), href)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return filepath.Join(filepath.Dir(archive), filepath.FromSlash(resolved))
+	}
+	return ArchivePath(archive, resolved)
+}
+
+// ファイル（アーカイブ内のファイルを含む）が存在するか
+func fileExists(filename string) (bool, error) {
+	if archive, entry, ok := splitArchivePath(filename); ok {
+		r, err := OpenArchive(archive)
+		if err != nil {
+			return false, err
+		}
+		for _, f := range r.File {
+			if f.Name == entry {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	_, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ZIPファイルを読み込む。レポートパッケージでなければ EDINET の提出書類として読む
+// EDINET の提出書類は XBRL/PublicDoc と XBRL/AuditDoc のマニフェストを探し、PublicDoc のマニフェストに AuditDoc のインスタンスを加える
+func ParseArchive(archive string) (*model.Manifest, error) {
+	r, err := OpenArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	if isReportPackage(archive, r) {
+		return ParseReportPackage(archive)
+	}
+
+	var manifests []string
+	for _, dir := range []string{"XBRL/PublicDoc/", "XBRL/AuditDoc/"} {
+		var found []string
+		for _, f := range r.File {
+			name := f.Name
+			i := strings.Index(name, dir)
+			if i < 0 || (i > 0 && name[i-1] != '/') {
+				continue
+			}
+			base := name[i+len(dir):]
+			if !strings.Contains(base, "/") && strings.HasPrefix(base, "manifest") && strings.HasSuffix(base, ".xml") {
+				found = append(found, name)
+			}
+		}
+		sort.Strings(found)
+		manifests = append(manifests, found...)
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("❌ ZIPファイルにマニフェストが見つかりません: %s", archive)
+	}
+
+	var result *model.Manifest
+	for _, entry := range manifests {
+		manifest, err := ParseManifest(ArchivePath(archive, entry))
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = manifest
+			continue
+		}
+		result.List.Instances = append(result.List.Instances, manifest.List.Instances...)
+		result.List.XBRLInstances = append(result.List.XBRLInstances, manifest.List.XBRLInstances...)
+	}
+	return result, nil
+}
@@ -0,0 +1,313 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"thermal/model"
+	"time"
+)
+
+// 🔖 リモートのファイルを取得するときの設定
+type CacheConfig struct {
+	Dir     string        // キャッシュディレクトリ（空ならキャッシュしない）
+	Offline bool          // true ならネットワークに接続せず、キャッシュに無ければエラーにする
+	Timeout time.Duration // 1回のHTTPリクエストのタイムアウト
+	Retries int           // 接続エラーと 5xx のときの再試行回数
+	MaxAge  time.Duration // この期間内に取得したものは再検証せずに使う
+}
+
+// キャッシュしたファイルの情報（ファイルの内容と同じ名前の .json に保存する）
+type CacheEntry struct {
+	URL          string    `json:"url" yaml:"URL"`
+	Path         string    `json:"-" yaml:"Path"`
+	Size         int64     `json:"size" yaml:"Size"`
+	ETag         string    `json:"etag,omitempty" yaml:"ETag"`
+	LastModified string    `json:"lastModified,omitempty" yaml:"LastModified"`
+	Fetched      time.Time `json:"fetched" yaml:"Fetched"`
+}
+
+var (
+	cacheMu     sync.Mutex
+	cacheConfig = defaultCacheConfig()
+)
+
+// 既定の設定。環境変数 THERMAL_CACHE_DIR・THERMAL_OFFLINE・THERMAL_HTTP_TIMEOUT で変更できる
+func defaultCacheConfig() CacheConfig {
+	config := CacheConfig{
+		Timeout: 30 * time.Second,
+		Retries: 2,
+		MaxAge:  24 * time.Hour,
+	}
+	if dir := os.Getenv("THERMAL_CACHE_DIR"); dir != "" {
+		config.Dir = dir
+	} else if dir, err := os.UserCacheDir(); err == nil {
+		config.Dir = filepath.Join(dir, "thermal")
+	}
+	if v := os.Getenv("THERMAL_OFFLINE"); v == "1" || v == "true" {
+		config.Offline = true
+	}
+	if d, err := time.ParseDuration(os.Getenv("THERMAL_HTTP_TIMEOUT")); err == nil {
+		config.Timeout = d
+	}
+	return config
+}
+
+// 現在の設定
+func GetCacheConfig() CacheConfig {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return cacheConfig
+}
+
+// 設定を変更する
+func SetCacheConfig(config CacheConfig) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cacheConfig = config
+}
+
+// URLに対応するキャッシュのファイル名（URLのハッシュ）
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func readCacheEntry(dir, url string) (*CacheEntry, []byte, bool) {
+	if dir == "" {
+		return nil, nil, false
+	}
+	path := filepath.Join(dir, cacheKey(url))
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, nil, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil || entry.URL != url {
+		return nil, nil, false
+	}
+	// 内容だけが置き換わった直後は情報と食い違うので、キャッシュに無いものとする
+	data, err := os.ReadFile(path)
+	if err != nil || int64(len(data)) != entry.Size {
+		return nil, nil, false
+	}
+	entry.Path = path
+	return &entry, data, true
+}
+
+// 内容を書いてから情報を書く（情報のあるものだけを有効なキャッシュとする）
+// どちらも一時ファイルに書いてから置き換えるので、途中で止まっても書きかけのファイルは残らない
+func writeCacheEntry(dir string, entry *CacheEntry, data []byte) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, cacheKey(entry.URL))
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	entry.Size = int64(len(data))
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path+".json", meta)
+}
+
+// 同じディレクトリの一時ファイルに書いてから rename で置き換える
+// 一時ファイル名は .json で終わらないので、キャッシュの一覧には出ない
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// リモートのファイルを取得する
+// キャッシュが MaxAge 以内ならそのまま使い、古ければ ETag・Last-Modified で再検証する
+// 取得に失敗したときは、古いキャッシュがあればそれを使う
+func fetchRemote(url string) ([]byte, error) {
+	config := GetCacheConfig()
+
+	entry, cached, ok := readCacheEntry(config.Dir, url)
+	if config.Offline {
+		if !ok {
+			return nil, fmt.Errorf("❌ オフラインのためキャッシュに無いファイルは取得できません: %s", url)
+		}
+		return cached, nil
+	}
+	if ok && config.MaxAge > 0 && time.Since(entry.Fetched) < config.MaxAge {
+		return cached, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("❌ XML取得失敗: %s", err)
+	}
+	if ok {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := doWithRetry(req, config)
+	if err != nil {
+		if ok {
+			Report(model.SeverityWarning, model.DiagStaleCache, url, "", "取得に失敗したため %s に取得したキャッシュを使います: %v", entry.Fetched.Format(time.RFC3339), err)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("❌ XML取得失敗: %s", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && ok:
+		entry.Fetched = time.Now()
+		storeCacheEntry(config.Dir, entry, cached)
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		if ok && resp.StatusCode >= 500 {
+			Report(model.SeverityWarning, model.DiagStaleCache, url, "", "取得に失敗したため %s に取得したキャッシュを使います: %s", entry.Fetched.Format(time.RFC3339), resp.Status)
+			return cached, nil
+		}
+		return nil, fmt.Errorf("❌ HTTPレスポンスエラー: %d %s", resp.StatusCode, url)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("❌ HTTPレスポンスのデータ読み込み失敗: %s", err)
+	}
+	entry = &CacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	storeCacheEntry(config.Dir, entry, data)
+	return data, nil
+}
+
+// キャッシュに書けなくても取得した内容は使えるので、警告として記録するだけにする
+func storeCacheEntry(dir string, entry *CacheEntry, data []byte) {
+	if err := writeCacheEntry(dir, entry, data); err != nil {
+		Report(model.SeverityWarning, model.DiagCacheWrite, entry.URL, "", "キャッシュに書き込めません（%s）: %v", dir, err)
+	}
+}
+
+// 接続エラーと 5xx のときは、間隔を空けて再試行する
+func doWithRetry(req *http.Request, config CacheConfig) (*http.Response, error) {
+	client := &http.Client{Timeout: config.Timeout}
+	var lastErr error
+	for attempt := 0; attempt <= config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 && attempt < config.Retries {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s", resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// キャッシュしたファイルの一覧（URLの順）
+func CacheEntries() ([]CacheEntry, error) {
+	dir := GetCacheConfig().Dir
+	if dir == "" {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	for _, file := range files {
+		meta, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry CacheEntry
+		if err := json.Unmarshal(meta, &entry); err != nil {
+			continue
+		}
+		entry.Path = strings.TrimSuffix(file, ".json")
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+	return entries, nil
+}
+
+// 取得してから olderThan 以上経ったキャッシュを削除する（0 なら全て）。削除した件数を返す
+func PruneCache(olderThan time.Duration) (int, error) {
+	entries, err := CacheEntries()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, entry := range entries {
+		if olderThan > 0 && time.Since(entry.Fetched) < olderThan {
+			continue
+		}
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+		if err := os.Remove(entry.Path + ".json"); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// URLのファイルを取得してキャッシュする。スキーマならDTS全体（インポートとリンクベース）を取得する
+func WarmCache(url string) error {
+	if !IsRemoteFile(url) {
+		return fmt.Errorf("not a URL: %s", url)
+	}
+	if strings.HasSuffix(strings.ToLower(url), ".xsd") {
+		_, err := ParseSchema(url, make(map[string]bool))
+		return err
+	}
+	_, err := GetXMLReader(url)
+	return err
+}
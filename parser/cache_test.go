@@ -0,0 +1,328 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"thermal/model"
+	"time"
+)
+
+// テスト用のキャッシュ設定にして、終了時に元に戻す
+func useCacheConfig(t *testing.T, config CacheConfig) {
+	t.Helper()
+	saved := GetCacheConfig()
+	SetCacheConfig(config)
+	ClearDiagnostics()
+	t.Cleanup(func() {
+		SetCacheConfig(saved)
+		ClearDiagnostics()
+	})
+}
+
+func testCacheConfig(t *testing.T) CacheConfig {
+	return CacheConfig{Dir: t.TempDir(), Timeout: 5 * time.Second}
+}
+
+// 記録された診断情報のうち、コードが一致するもの
+func diagnosticsWithCode(code string) []model.Diagnostic {
+	var result []model.Diagnostic
+	for _, d := range Diagnostics() {
+		if d.Code == code {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func fetch(t *testing.T, url string) string {
+	t.Helper()
+	data, err := fetchRemote(url)
+	if err != nil {
+		t.Fatalf("fetchRemote(%s): %v", url, err)
+	}
+	return string(data)
+}
+
+func TestFetchRemoteUsesFreshCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("<schema/>"))
+	}))
+	defer server.Close()
+
+	config := testCacheConfig(t)
+	config.MaxAge = time.Hour
+	useCacheConfig(t, config)
+
+	url := server.URL + "/a.xsd"
+	for range 2 {
+		if got := fetch(t, url); got != "<schema/>" {
+			t.Errorf("got %q", got)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1 (second fetch should be served from the cache)", n)
+	}
+
+	entries, err := CacheEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].URL != url || entries[0].Size != int64(len("<schema/>")) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestFetchRemoteRevalidatesWithETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("v1"))
+	}))
+	defer server.Close()
+
+	// MaxAge が 0 なら毎回再検証する
+	useCacheConfig(t, testCacheConfig(t))
+
+	url := server.URL + "/etag.xsd"
+	fetch(t, url)
+	first, _, _ := readCacheEntry(GetCacheConfig().Dir, url)
+	time.Sleep(10 * time.Millisecond)
+	if got := fetch(t, url); got != "v1" {
+		t.Errorf("got %q, want cached content", got)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("requests = %d, 304 = %d, want 2 and 1", requests.Load(), notModified.Load())
+	}
+
+	// 304 のときは取得日時だけを更新する
+	second, _, _ := readCacheEntry(GetCacheConfig().Dir, url)
+	if !second.Fetched.After(first.Fetched) || second.ETag != `"v1"` {
+		t.Errorf("entry after 304 = %+v, before = %+v", second, first)
+	}
+}
+
+func TestFetchRemoteRevalidatesWithLastModified(t *testing.T) {
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	var notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	useCacheConfig(t, testCacheConfig(t))
+
+	url := server.URL + "/lm.xml"
+	fetch(t, url)
+	if got := fetch(t, url); got != "body" {
+		t.Errorf("got %q", got)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("304 responses = %d, want 1", notModified.Load())
+	}
+}
+
+func TestFetchRemoteUpdatesChangedContent(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := version.Add(1)
+		w.Write([]byte(strings.Repeat("x", int(v))))
+	}))
+	defer server.Close()
+
+	useCacheConfig(t, testCacheConfig(t))
+
+	url := server.URL + "/changed.xml"
+	fetch(t, url)
+	if got := fetch(t, url); got != "xx" {
+		t.Errorf("got %q, want the new content", got)
+	}
+	if _, cached, _ := readCacheEntry(GetCacheConfig().Dir, url); string(cached) != "xx" {
+		t.Errorf("cached %q, want the new content", cached)
+	}
+}
+
+func TestFetchRemoteFallsBackToStaleCacheOn5xx(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("stale"))
+	}))
+	defer server.Close()
+
+	useCacheConfig(t, testCacheConfig(t))
+
+	url := server.URL + "/stale.xsd"
+	fetch(t, url)
+	failing.Store(true)
+	if got := fetch(t, url); got != "stale" {
+		t.Errorf("got %q, want stale cached content", got)
+	}
+	if d := diagnosticsWithCode(model.DiagStaleCache); len(d) != 1 || d[0].File != url || d[0].Severity != model.SeverityWarning {
+		t.Errorf("stale-cache diagnostics = %+v", d)
+	}
+}
+
+func TestFetchRemoteFailsOn5xxWithoutCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	useCacheConfig(t, testCacheConfig(t))
+
+	if _, err := fetchRemote(server.URL + "/missing.xsd"); err == nil {
+		t.Error("want an error when the server fails and nothing is cached")
+	}
+}
+
+func TestFetchRemoteRetries5xx(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	config := testCacheConfig(t)
+	config.Retries = 1
+	useCacheConfig(t, config)
+
+	if got := fetch(t, server.URL+"/retry.xsd"); got != "ok" {
+		t.Errorf("got %q", got)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+}
+
+func TestFetchRemoteOffline(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("cached"))
+	}))
+	defer server.Close()
+
+	config := testCacheConfig(t)
+	useCacheConfig(t, config)
+	cachedURL := server.URL + "/cached.xsd"
+	fetch(t, cachedURL)
+
+	config.Offline = true
+	SetCacheConfig(config)
+
+	// キャッシュにあるものは、古くてもネットワークに接続せずに使う
+	if got := fetch(t, cachedURL); got != "cached" {
+		t.Errorf("got %q", got)
+	}
+	_, err := fetchRemote(server.URL + "/uncached.xsd")
+	if err == nil || !strings.Contains(err.Error(), "オフライン") {
+		t.Errorf("err = %v, want an offline cache miss", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("requests = %d, want 1 (no requests while offline)", requests.Load())
+	}
+}
+
+func TestFetchRemoteReportsCacheWriteFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	// キャッシュディレクトリの場所にファイルがあると書き込めない
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := testCacheConfig(t)
+	config.Dir = blocked
+	useCacheConfig(t, config)
+
+	url := server.URL + "/data.xml"
+	if got := fetch(t, url); got != "data" {
+		t.Errorf("got %q, want the fetched content even if it cannot be cached", got)
+	}
+	if d := diagnosticsWithCode(model.DiagCacheWrite); len(d) != 1 || d[0].File != url {
+		t.Errorf("cache-write diagnostics = %+v", d)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	useCacheConfig(t, testCacheConfig(t))
+	fetch(t, server.URL+"/a.xsd")
+	fetch(t, server.URL+"/b.xsd")
+
+	if n, err := PruneCache(time.Hour); err != nil || n != 0 {
+		t.Errorf("PruneCache(1h) = %d, %v, want nothing pruned", n, err)
+	}
+	if n, err := PruneCache(0); err != nil || n != 2 {
+		t.Errorf("PruneCache(0) = %d, %v, want 2", n, err)
+	}
+	if entries, _ := CacheEntries(); len(entries) != 0 {
+		t.Errorf("entries after prune = %+v", entries)
+	}
+}
+
+func TestWriteCacheEntryLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	url := "http://example.com/a.xsd"
+	for _, data := range []string{"first", "second version"} {
+		if err := writeCacheEntry(dir, &CacheEntry{URL: url}, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if _, got, ok := readCacheEntry(dir, url); !ok || string(got) != data {
+			t.Errorf("readCacheEntry = %q, %v, want %q", got, ok, data)
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		t.Errorf("files in cache dir = %v, want the content and its .json only", names)
+	}
+
+	// 内容が情報と食い違うもの（置き換えの途中）はキャッシュに無いものとする
+	if err := os.WriteFile(filepath.Join(dir, cacheKey(url)), []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, got, ok := readCacheEntry(dir, url); ok {
+		t.Errorf("readCacheEntry = %q, want a miss for content not matching its size", got)
+	}
+}
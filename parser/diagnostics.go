@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"thermal/model"
+)
+
+// 読込み中に見つかった問題を記録する（同じ内容は1回だけ）
+var (
+	diagnosticsMu   sync.Mutex
+	diagnostics     []model.Diagnostic
+	diagnosticsSeen = make(map[model.Diagnostic]bool)
+	strictMode      = defaultStrict()
+)
+
+func defaultStrict() bool {
+	v := os.Getenv("THERMAL_STRICT")
+	return v == "1" || v == "true"
+}
+
+// 診断情報を記録する
+func Report(severity model.Severity, code, file, element, format string, args ...any) {
+	d := model.Diagnostic{
+		Severity: severity,
+		Code:     code,
+		File:     file,
+		Element:  element,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	if diagnosticsSeen[d] {
+		return
+	}
+	diagnosticsSeen[d] = true
+	diagnostics = append(diagnostics, d)
+}
+
+// 記録した診断情報を記録順に返す
+func Diagnostics() []model.Diagnostic {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	return append([]model.Diagnostic(nil), diagnostics...)
+}
+
+// 記録した診断情報を消去する
+func ClearDiagnostics() {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	diagnostics = nil
+	diagnosticsSeen = make(map[model.Diagnostic]bool)
+}
+
+// 厳格モードでは壊れたアーク・ロケータで関係の解決を中断し、そうでなければ読み飛ばす
+func Strict() bool {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	return strictMode
+}
+
+func SetStrict(strict bool) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	strictMode = strict
+}
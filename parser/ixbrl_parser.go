@@ -3,8 +3,10 @@ package parser
 import (
 	"encoding/xml"
 	"fmt"
+	"maps"
 	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"thermal/model"
@@ -37,7 +39,8 @@ func getPrefixByNamespaceURI(nsMap map[string]string, targetURI string) string {
 	return ""
 }
 
-func resolveXMLName(tag string, nsMap map[string]string) xml.Name {
+// QName（prefix:local）を名前空間付きの名前に解決する
+func ResolveXMLName(tag string, nsMap map[string]string) xml.Name {
 	var space, local string
 	parts := strings.SplitN(tag, ":", 2)
 
@@ -95,7 +98,7 @@ func warekiToSeireki(date string) (string, error) {
 	}
 
 	// 年月日 or 年月 に対応
-	re := regexp.MustCompile(`(明治|大正|昭和|平成|令和)(元|[0-9０-９]+)年([0-9０-９]+)月(?:([0-9０-９]+)日)?`)
+	re := regexp.MustCompile(`^(明治|大正|昭和|平成|令和)(元|[0-9０-９]+)年([0-9０-９]+)月(?:([0-9０-９]+)日)?$`)
 	matches := re.FindStringSubmatch(date)
 	if matches == nil {
 		return "", fmt.Errorf("不正な形式: %s", date)
@@ -131,7 +134,7 @@ func jpDateToISO(jp string) (string, error) {
 	}
 
 	// 年月日 or 年月 のパターン対応
-	re := regexp.MustCompile(`([0-9０-９]+)年([0-9０-９]+)月(?:([0-9０-９]+)日)?`)
+	re := regexp.MustCompile(`^([0-9０-９]+)年([0-9０-９]+)月(?:([0-9０-９]+)日)?$`)
 	matches := re.FindStringSubmatch(jp)
 	if matches == nil {
 		return "", fmt.Errorf("不正な形式: %s", jp)
@@ -148,86 +151,260 @@ func jpDateToISO(jp string) (string, error) {
 	return fmt.Sprintf("%04d-%02d-%02d", y, m, d), nil // 年月日
 }
 
-func parseInlineXBRL(inlineXBRLFile string, instance *model.XBRLInstance) error {
+// Inline XBRL 1.0 / 1.1 の名前空間
+const inlineXBRL10Namespace = "http://www.xbrl.org/2008/inlineXBRL"
 
+var inlineXBRLNamespaces = []string{
+	inlineXBRL10Namespace,
+	"http://www.xbrl.org/2013/inlineXBRL",
+}
+
+const (
+	defaultLinkRole     = "http://www.xbrl.org/2003/role/link"
+	defaultFootnoteRole = "http://www.xbrl.org/2003/role/footnote"
+	factFootnoteArcRole = "http://www.xbrl.org/2003/arcrole/fact-footnote"
+)
+
+// 複数のInlineXBRL文書にまたがる解析状態
+type inlineDocumentSet struct {
+	continuations map[string]*xmlquery.Node
+	referenced    map[string]bool // 参照済みの ix:continuation
+	footnotes     map[string]model.Footnote
+	footnoteArcs  map[string]inlineFootnoteArc // Inline XBRL 1.0 の脚注ID → アークロール・リンクロール
+	relationships []inlineRelationship
+}
+
+// Inline XBRL 1.0 の ix:footnote に書かれたアークロールとリンクロール
+type inlineFootnoteArc struct {
+	arcRole  string
+	linkRole string
+}
+
+// ix:relationship（またはファクトの footnoteRefs）による関係
+type inlineRelationship struct {
+	fromRefs []string
+	toRefs   []string
+	arcRole  string
+	linkRole string
+	order    string
+}
+
+// 読み込んだInlineXBRL文書
+type inlineDocument struct {
+	path  string
+	nodes []*xmlquery.Node // 文書中の全要素（ix:exclude 除去前に取得）
+	nsMap map[string]string
+}
+
+func isInlineXBRLElement(node *xmlquery.Node, local string) bool {
+	return node.Type == xmlquery.ElementNode && node.Data == local && slices.Contains(inlineXBRLNamespaces, node.NamespaceURI)
+}
+
+func loadInlineDocument(inlineXBRLFile string) (*inlineDocument, error) {
 	r, err := GetXMLReader(inlineXBRLFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	doc, err := xmlquery.Parse(r)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	nodes := xmlquery.Find(doc, "//*")
+
+	// ix:exclude の範囲はファクトの値に含めないため、ツリーから取り除く
+	for _, node := range nodes {
+		if isInlineXBRLElement(node, "exclude") {
+			xmlquery.RemoveFromTree(node)
+		}
 	}
 
+	return &inlineDocument{
+		path:  inlineXBRLFile,
+		nodes: nodes,
+		nsMap: extractNamespaceMap(doc),
+	}, nil
+}
+
+// 全InlineXBRL文書の ix:continuation を id ごとにまとめる
+func collectContinuations(docs []*inlineDocument) (map[string]*xmlquery.Node, error) {
+	continuations := make(map[string]*xmlquery.Node)
+	for _, d := range docs {
+		for _, node := range d.nodes {
+			if !isInlineXBRLElement(node, "continuation") {
+				continue
+			}
+			id := node.SelectAttr("id")
+			if _, exists := continuations[id]; exists {
+				return nil, fmt.Errorf("ix:continuationのidが重複しています: %s (%s)", id, d.path)
+			}
+			continuations[id] = node
+		}
+	}
+	return continuations, nil
+}
+
+// 要素の内容を取得する。escape=true の場合はタグごと取得する
+func nodeContent(node *xmlquery.Node, escape bool) string {
+	if !escape {
+		return node.InnerText()
+	}
+	innerXML := ""
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		innerXML += child.OutputXML(true)
+	}
+	return innerXML
+}
+
+// continuedAt をたどり、ファクトの内容を連結して返す
+func continuedContent(node *xmlquery.Node, escape bool, continuations map[string]*xmlquery.Node, referenced map[string]bool) (string, error) {
+	content := nodeContent(node, escape)
+
+	visited := make(map[string]bool)
+	for next := node.SelectAttr("continuedAt"); next != ""; {
+		if visited[next] {
+			return "", fmt.Errorf("ix:continuationが循環しています: %s", next)
+		}
+		visited[next] = true
+
+		if referenced[next] {
+			return "", fmt.Errorf("ix:continuationが複数箇所から参照されています: %s", next)
+		}
+		referenced[next] = true
+
+		continuation, ok := continuations[next]
+		if !ok {
+			return "", fmt.Errorf("ix:continuationが見つかりません: %s", next)
+		}
+		content += nodeContent(continuation, escape)
+		next = continuation.SelectAttr("continuedAt")
+	}
+	return content, nil
+}
+
+func parseInlineXBRL(d *inlineDocument, set *inlineDocumentSet, instance *model.XBRLInstance) error {
+
 	// 名前空間対応表作成
-	nsMap := extractNamespaceMap(doc)
+	nsMap := d.nsMap
 
-	for _, node := range xmlquery.Find(doc, "//*") {
-		if (node.Data == "nonNumeric" || node.Data == "nonFraction") && node.NamespaceURI == "http://www.xbrl.org/2008/inlineXBRL" {
+	for _, node := range d.nodes {
+		if isInlineXBRLElement(node, "nonNumeric") || isInlineXBRLElement(node, "nonFraction") {
 			// Fact
 			name := node.SelectAttr("name")
 			xsi := getPrefixByNamespaceURI(nsMap, "http://www.w3.org/2001/XMLSchema-instance")
-			xsinil := fmt.Sprintf("%s:nil", xsi)
+			xsinil := node.SelectAttr(fmt.Sprintf("%s:nil", xsi))
 			escape := node.SelectAttr("escape")
 			sign := node.SelectAttr("sign")
 			text := ""
+			transformError := ""
 			if xsinil != "true" {
+				content, err := continuedContent(node, escape == "true", set.continuations, set.referenced)
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
 				if escape == "true" {
 					// テキストブロックの場合（タグごと）
-					innerXML := ""
-					for child := node.FirstChild; child != nil; child = child.NextSibling {
-						innerXML += child.OutputXML(true)
-					}
-					text = innerXML
+					text = content
 				} else {
-					text = node.InnerText()
-					format := node.SelectAttr("format")
-					if strings.HasSuffix(format, ":numdotdecimal") {
-						text = strings.ReplaceAll(text, ",", "")
-					} else if strings.HasSuffix(format, ":dateerayearmonthdayjp") {
-						// 和暦年月日変換
-						s, err := warekiToSeireki(text)
-						if err != nil {
-							text = s
-						}
-					} else if strings.HasSuffix(format, ":dateerayearmonthjp") {
-						// 和暦年月変換
-						s, err := warekiToSeireki(text)
-						if err != nil {
-							text = s
-						}
-					} else if strings.HasSuffix(format, ":dateyearmonthdaycjk") {
-						// 年月日変換
-						s, err := jpDateToISO(text)
-						if err != nil {
-							text = s
-						}
-					} else if strings.HasSuffix(format, ":dateyearmonthcjk") {
-						// 年月変換
-						s, err := jpDateToISO(text)
+					text = content
+					if format := node.SelectAttr("format"); format != "" {
+						// 変換に失敗した場合は元の文字列を残し、エラーをファクトに記録する
+						s, err := Transform(ResolveXMLName(format, nsMap), text)
 						if err != nil {
+							transformError = err.Error()
+							Report(model.SeverityWarning, model.DiagTransform, d.path, name, "ix:format の変換に失敗: %v", err)
+						} else {
 							text = s
 						}
+					} else if node.Data == "nonFraction" {
+						text = strings.TrimSpace(text)
 					}
 
-					scale := node.SelectAttr("scale")
-					scaleNum, err := strconv.Atoi(scale)
-					if err == nil && scaleNum != 0 {
-						text = ShiftDecimal(text, scaleNum)
+					if transformError == "" {
+						scale := node.SelectAttr("scale")
+						scaleNum, err := strconv.Atoi(scale)
+						if err == nil && scaleNum != 0 {
+							text = ShiftDecimal(text, scaleNum)
+						}
 					}
 					text = sign + text
 				}
 			}
-			// TODO:トランスフォーメーションルールの実装
+			// xml:lang は非数値のファクトだけに付ける
+			lang := ""
+			if node.Data == "nonNumeric" {
+				lang = inheritedLang(node)
+			}
+			// Inline XBRL 1.0 では脚注への参照をファクトの属性で指定する
+			// アークロールとリンクロールは参照先の ix:footnote に書かれるため、全文書を読んだ後で決める
+			// id の無いファクトは関係の起点にできないため、参照を読み飛ばす
+			refs := strings.Fields(node.SelectAttr("footnoteRefs"))
+			if len(refs) > 0 && node.SelectAttr("id") == "" {
+				Report(model.SeverityError, model.DiagInvalidArc, d.path, name, "footnoteRefs を持つファクトに id がありません: %s", strings.Join(refs, " "))
+				if Strict() {
+					return fmt.Errorf("%s: footnoteRefs を持つファクトに id がありません", name)
+				}
+				refs = nil
+			}
+			for _, ref := range refs {
+				set.relationships = append(set.relationships, inlineRelationship{
+					fromRefs: []string{node.SelectAttr("id")},
+					toRefs:   []string{ref},
+				})
+			}
+
 			instance.Facts = append(instance.Facts, model.Fact{
-				XMLName:    resolveXMLName(name, nsMap),
-				ContextRef: node.SelectAttr("contextRef"),
-				UnitRef:    node.SelectAttr("unitRef"),
-				Decimals:   node.SelectAttr("decimals"),
-				Nil:        node.SelectAttr(xsinil),
-				Value:      text,
+				XMLName:        ResolveXMLName(name, nsMap),
+				ID:             node.SelectAttr("id"),
+				ContextRef:     node.SelectAttr("contextRef"),
+				UnitRef:        node.SelectAttr("unitRef"),
+				Decimals:       node.SelectAttr("decimals"),
+				Nil:            xsinil,
+				Lang:           lang,
+				Value:          text,
+				TransformError: transformError,
 			})
+		} else if isInlineXBRLElement(node, "footnote") {
+			id := node.SelectAttr("id")
+			if node.NamespaceURI == inlineXBRL10Namespace {
+				// Inline XBRL 1.0 では footnoteID で識別し、アークロールとリンクロールも脚注に書く
+				id = node.SelectAttr("footnoteID")
+				set.footnoteArcs[id] = inlineFootnoteArc{
+					arcRole:  node.SelectAttr("arcrole"),
+					linkRole: node.SelectAttr("footnoteLinkRole"),
+				}
+			}
+			content, err := continuedContent(node, true, set.continuations, set.referenced)
+			if err != nil {
+				return fmt.Errorf("%s: %v", id, err)
+			}
+			role := node.SelectAttr("footnoteRole")
+			if role == "" {
+				role = defaultFootnoteRole
+			}
+			set.footnotes[id] = model.Footnote{
+				Label: id,
+				ID:    id,
+				Role:  role,
+				Lang:  inheritedLang(node),
+				Value: content,
+			}
+		} else if isInlineXBRLElement(node, "relationship") {
+			rel := inlineRelationship{
+				fromRefs: strings.Fields(node.SelectAttr("fromRefs")),
+				toRefs:   strings.Fields(node.SelectAttr("toRefs")),
+				arcRole:  node.SelectAttr("arcrole"),
+				linkRole: node.SelectAttr("linkRole"),
+				order:    node.SelectAttr("order"),
+			}
+			if rel.arcRole == "" {
+				rel.arcRole = factFootnoteArcRole
+			}
+			if rel.linkRole == "" {
+				rel.linkRole = defaultLinkRole
+			}
+			set.relationships = append(set.relationships, rel)
 		} else if node.Data == "schemaRef" && node.NamespaceURI == "http://www.xbrl.org/2003/linkbase" {
 			// schemaRef要素の xlink:href 属性の値を取得
 			xlink := getPrefixByNamespaceURI(nsMap, "http://www.w3.org/1999/xlink")
@@ -250,6 +427,7 @@ func parseInlineXBRL(inlineXBRLFile string, instance *model.XBRLInstance) error
 			if err := decoder.Decode(&unit); err != nil {
 				return err
 			}
+			resolveUnitMeasures(&unit, nsMap)
 			instance.Units = append(instance.Units, unit)
 		} else if node.Data == "roleRef" && node.NamespaceURI == "http://www.xbrl.org/2003/linkbase" {
 			xlink := getPrefixByNamespaceURI(nsMap, "http://www.w3.org/1999/xlink")
@@ -264,16 +442,105 @@ func parseInlineXBRL(inlineXBRLFile string, instance *model.XBRLInstance) error
 	return nil
 }
 
+// xml:lang は祖先要素から継承する
+func inheritedLang(node *xmlquery.Node) string {
+	for n := node; n != nil; n = n.Parent {
+		if lang := n.SelectAttr("xml:lang"); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+// ix:footnote と ix:relationship から、XBRLインスタンスと同じ形の脚注リンクを組み立てる
+func buildFootnoteLinks(set *inlineDocumentSet) []model.FootnoteLink {
+	var links []model.FootnoteLink
+	linkIndex := make(map[string]int)
+	labels := make(map[string]map[string]bool) // リンクロールごとの追加済みラベル
+
+	for _, rel := range set.relationships {
+		if rel.arcRole == "" {
+			// footnoteRefs による関係は、参照先の脚注のアークロール・リンクロールを使う
+			arc := set.footnoteArcs[rel.toRefs[0]]
+			rel.arcRole, rel.linkRole = arc.arcRole, arc.linkRole
+			if rel.arcRole == "" {
+				rel.arcRole = factFootnoteArcRole
+			}
+			if rel.linkRole == "" {
+				rel.linkRole = defaultLinkRole
+			}
+		}
+		i, ok := linkIndex[rel.linkRole]
+		if !ok {
+			i = len(links)
+			linkIndex[rel.linkRole] = i
+			links = append(links, model.FootnoteLink{Role: rel.linkRole})
+			labels[rel.linkRole] = make(map[string]bool)
+		}
+		link := &links[i]
+		added := labels[rel.linkRole]
+
+		// ファクトはロケータ、脚注はリソースとして追加する
+		addRef := func(id string) {
+			if added[id] {
+				return
+			}
+			added[id] = true
+			if footnote, ok := set.footnotes[id]; ok {
+				link.Footnotes = append(link.Footnotes, footnote)
+			} else {
+				link.Locs = append(link.Locs, model.Loc{Label: id, Href: "#" + id})
+			}
+		}
+
+		for _, from := range rel.fromRefs {
+			addRef(from)
+			for _, to := range rel.toRefs {
+				addRef(to)
+				link.Arcs = append(link.Arcs, model.FootnoteArc{
+					ArcBase: model.ArcBase{From: from, To: to},
+					ArcRole: rel.arcRole,
+					Order:   rel.order,
+				})
+			}
+		}
+	}
+	return links
+}
+
 func ParseInlineXBRLs(inlineXBRLFiles []string, instanceFile string) (*model.XBRLInstance, error) {
 
-	xbrlInstance := &model.XBRLInstance{}
+	xbrlInstance := &model.XBRLInstance{Namespaces: make(map[string]string)}
 
+	// ix:continuation は文書をまたいで参照されるため、先に全文書を読み込む
+	docs := make([]*inlineDocument, 0, len(inlineXBRLFiles))
 	for _, inlineXBRLFile := range inlineXBRLFiles {
-		err := parseInlineXBRL(inlineXBRLFile, xbrlInstance)
+		d, err := loadInlineDocument(inlineXBRLFile)
 		if err != nil {
 			return nil, fmt.Errorf("❌ InlineXBRLのパースに失敗:%v", err)
 		}
+		docs = append(docs, d)
+		maps.Copy(xbrlInstance.Namespaces, d.nsMap)
+	}
+
+	continuations, err := collectContinuations(docs)
+	if err != nil {
+		return nil, fmt.Errorf("❌ InlineXBRLのパースに失敗:%v", err)
+	}
+
+	set := &inlineDocumentSet{
+		continuations: continuations,
+		referenced:    make(map[string]bool),
+		footnotes:     make(map[string]model.Footnote),
+		footnoteArcs:  make(map[string]inlineFootnoteArc),
+	}
+	for _, d := range docs {
+		err := parseInlineXBRL(d, set, xbrlInstance)
+		if err != nil {
+			return nil, fmt.Errorf("❌ InlineXBRLのパースに失敗:%s: %v", d.path, err)
+		}
 	}
+	xbrlInstance.FootnoteLink = buildFootnoteLinks(set)
 
 	xbrlInstance.Path = instanceFile
 
@@ -2,7 +2,6 @@ package parser
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"thermal/model"
 )
@@ -28,12 +27,11 @@ func ParseManifest(path string) (*model.Manifest, error) {
 		// インスタンスがリモートファイルでなく、かつ、存在しなければ、InlineXBRLを代わりに読み込む
 		readFromIXBRL := false
 		if !IsRemoteFile(instanceFile) {
-			_, err := os.Stat(instanceFile)
-			if os.IsNotExist(err) {
-				readFromIXBRL = true
-			} else if err != nil {
+			exists, err := fileExists(instanceFile)
+			if err != nil {
 				return nil, fmt.Errorf("❌ XBRLインスタンスの存在チェックに失敗:%v", err)
 			}
+			readFromIXBRL = !exists
 		}
 
 		// インスタンスの解析
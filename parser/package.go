@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"thermal/model"
+)
+
+// カタログの書き換え規則（開始文字列を書き換え先に置き換える）
+type rewriteRule struct {
+	start    string
+	prefix   string
+	optional bool // 書き換え先のファイルが無ければ元のURLのまま（ネットワークから取得する）
+}
+
+// 読み込んだタクソノミパッケージ
+var (
+	packagesMu   sync.Mutex
+	packages     []*model.TaxonomyPackage
+	rewriteRules []rewriteRule
+)
+
+// タクソノミパッケージ（ZIPファイル）を読み込み、カタログの書き換え規則を登録する
+// 以後 GetXMLReader で読むURLは、書き換え先のパッケージ内のファイルから読む
+func LoadTaxonomyPackage(filename string) (*model.TaxonomyPackage, error) {
+	packagesMu.Lock()
+	defer packagesMu.Unlock()
+
+	for _, pkg := range packages {
+		if pkg.Path == filename {
+			return pkg, nil
+		}
+	}
+
+	r, err := OpenArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// META-INF はパッケージの最上位ディレクトリの直下にある
+	metaInf := ""
+	for _, f := range r.File {
+		dir, base := path.Split(f.Name)
+		if base != "taxonomyPackage.xml" || !strings.HasSuffix(dir, "META-INF/") || strings.Count(dir, "/") > 2 {
+			continue
+		}
+		if metaInf == "" || len(dir) < len(metaInf) {
+			metaInf = dir
+		}
+	}
+	if metaInf == "" {
+		return nil, fmt.Errorf("❌ taxonomyPackage.xml が見つかりません: %s", filename)
+	}
+
+	tpFile := ArchivePath(filename, metaInf+"taxonomyPackage.xml")
+	pkg, err := ParseXML[model.TaxonomyPackage](tpFile)
+	if err != nil {
+		return nil, fmt.Errorf("❌ taxonomyPackage.xml のパースに失敗: %s: %v", filename, err)
+	}
+	pkg.Path = filename
+
+	// エントリーポイントの相対パスはパッケージ内のファイルにする
+	for i := range pkg.EntryPoints {
+		for j := range pkg.EntryPoints[i].Documents {
+			doc := &pkg.EntryPoints[i].Documents[j]
+			doc.Href = ResolveHref(tpFile, strings.TrimSpace(doc.Href))
+		}
+	}
+
+	// カタログは省略できる
+	catalogFile := ArchivePath(filename, metaInf+"catalog.xml")
+	exists, err := fileExists(catalogFile)
+	if err != nil {
+		return nil, err
+	}
+	var rules []rewriteRule
+	if exists {
+		catalog, err := ParseXML[model.PackageCatalog](catalogFile)
+		if err != nil {
+			return nil, fmt.Errorf("❌ catalog.xml のパースに失敗: %s: %v", filename, err)
+		}
+		pkg.Catalog = catalog
+
+		add := func(start, prefix string) {
+			rules = append(rules, rewriteRule{start: start, prefix: resolveRewritePrefix(catalogFile, prefix)})
+		}
+		for _, rule := range catalog.RewriteURIs {
+			add(rule.StartString, rule.RewritePrefix)
+		}
+		for _, rule := range catalog.RewriteSystem {
+			add(rule.StartString, rule.RewritePrefix)
+		}
+	}
+
+	packages = append(packages, pkg)
+	addRewriteRules(rules)
+	return pkg, nil
+}
+
+// 書き換え規則を登録する。長い開始文字列の規則を優先する
+// 呼び出し側で packagesMu をロックしておく
+func addRewriteRules(rules []rewriteRule) {
+	rewriteRules = append(rewriteRules, rules...)
+	sort.SliceStable(rewriteRules, func(i, j int) bool {
+		return len(rewriteRules[i].start) > len(rewriteRules[j].start)
+	})
+}
+
+// 📂 展開済みのタクソノミのディレクトリを、カタログの rewriteURI と同じ規則として登録する
+// url で始まるURLは dir 以下にファイルがあればそれを読み、無ければネットワークから取得する
+func AddTaxonomyDirectory(url, dir string) {
+	packagesMu.Lock()
+	defer packagesMu.Unlock()
+	addRewriteRules([]rewriteRule{{start: url, prefix: strings.TrimSuffix(dir, "/") + "/", optional: true}})
+}
+
+// 書き換え先をカタログのファイルからの相対パスとして解決する（末尾の / は残す）
+func resolveRewritePrefix(catalogFile, prefix string) string {
+	resolved := ResolveHref(catalogFile, prefix)
+	if strings.HasSuffix(prefix, "/") && !strings.HasSuffix(resolved, "/") {
+		resolved += "/"
+	}
+	return resolved
+}
+
+// 読み込んだタクソノミパッケージの一覧
+func TaxonomyPackages() []*model.TaxonomyPackage {
+	packagesMu.Lock()
+	defer packagesMu.Unlock()
+	return append([]*model.TaxonomyPackage{}, packages...)
+}
+
+// URLをカタログの書き換え規則で書き換える。一致する規則が無ければそのまま返す
+func rewriteURL(url string) string {
+	packagesMu.Lock()
+	defer packagesMu.Unlock()
+	for _, rule := range rewriteRules {
+		if !strings.HasPrefix(url, rule.start) {
+			continue
+		}
+		rewritten := rule.prefix + strings.TrimPrefix(url, rule.start)
+		if rule.optional {
+			if _, err := os.Stat(rewritten); err != nil {
+				continue
+			}
+		}
+		return rewritten
+	}
+	return url
+}
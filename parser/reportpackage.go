@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"thermal/model"
+)
+
+// レポートパッケージの reports ディレクトリにあるレポートの種類（拡張子）
+var reportKinds = map[string]string{
+	".xhtml": "inline",
+	".html":  "inline",
+	".htm":   "inline",
+	".json":  "xbrl-json",
+	".xbrl":  "xbrl",
+}
+
+// レポートパッケージの最上位ディレクトリ（全エントリに共通の先頭のディレクトリ）
+func reportPackageRoot(r *zip.Reader) string {
+	root := ""
+	for _, f := range r.File {
+		i := strings.Index(f.Name, "/")
+		if i < 0 {
+			return ""
+		}
+		if root == "" {
+			root = f.Name[:i+1]
+		} else if f.Name[:i+1] != root {
+			return ""
+		}
+	}
+	return root
+}
+
+// レポートパッケージか（.xbri・.xbr、又は reportPackage.json か reports ディレクトリのあるZIPファイル）
+func isReportPackage(archive string, r *zip.Reader) bool {
+	ext := strings.ToLower(path.Ext(archive))
+	if ext == ".xbri" || ext == ".xbr" {
+		return true
+	}
+	root := reportPackageRoot(r)
+	if root == "" {
+		return false
+	}
+	for _, f := range r.File {
+		if f.Name == root+"META-INF/reportPackage.json" || strings.HasPrefix(f.Name, root+"reports/") {
+			return true
+		}
+	}
+	return false
+}
+
+// レポートパッケージ（ESEF の提出書類を含む）を読み込む
+// reports ディレクトリのレポートごとにインスタンスを作り、EDINET のマニフェストと同じ形にまとめる
+// reports 直下のファイルはそれぞれ1つのレポート、サブディレクトリはその中のファイル全体で1つのレポート（InlineXBRL文書セット）とする
+func ParseReportPackage(archive string) (*model.Manifest, error) {
+	r, err := OpenArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	root := reportPackageRoot(r)
+	if root == "" {
+		return nil, fmt.Errorf("❌ レポートパッケージの最上位ディレクトリが1つではありません: %s", archive)
+	}
+
+	// reportPackage.json は .xbri と .xbr では必須
+	jsonFile := ArchivePath(archive, root+"META-INF/reportPackage.json")
+	exists, err := fileExists(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		data, err := readArchiveFile(jsonFile)
+		if err != nil {
+			return nil, err
+		}
+		var rp model.ReportPackageJSON
+		if err := json.Unmarshal(data, &rp); err != nil {
+			return nil, fmt.Errorf("❌ reportPackage.json のパースに失敗: %v", err)
+		}
+		if !strings.HasPrefix(rp.DocumentInfo.DocumentType, model.ReportPackageDocumentType) {
+			return nil, fmt.Errorf("❌ 未対応のレポートパッケージです: %s", rp.DocumentInfo.DocumentType)
+		}
+	} else if ext := strings.ToLower(path.Ext(archive)); ext == ".xbri" || ext == ".xbr" {
+		return nil, fmt.Errorf("❌ reportPackage.json が見つかりません: %s", archive)
+	}
+
+	// 拡張タクソノミ（ESEF）はパッケージ内のカタログで解決する
+	exists, err = fileExists(ArchivePath(archive, root+"META-INF/taxonomyPackage.xml"))
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if _, err := LoadTaxonomyPackage(archive); err != nil {
+			return nil, err
+		}
+	}
+
+	// reports 直下のファイルと、サブディレクトリ直下のファイルを集める
+	reportsDir := root + "reports/"
+	var files []string
+	subdirs := make(map[string][]string)
+	for _, f := range r.File {
+		if !strings.HasPrefix(f.Name, reportsDir) {
+			continue
+		}
+		rel := strings.TrimPrefix(f.Name, reportsDir)
+		if _, ok := reportKinds[strings.ToLower(path.Ext(rel))]; !ok {
+			continue
+		}
+		switch parts := strings.Split(rel, "/"); len(parts) {
+		case 1:
+			files = append(files, f.Name)
+		case 2:
+			subdirs[parts[0]] = append(subdirs[parts[0]], f.Name)
+		}
+	}
+
+	var reports [][]string
+	if len(files) > 0 {
+		sort.Strings(files)
+		for _, file := range files {
+			reports = append(reports, []string{file})
+		}
+	} else {
+		names := make([]string, 0, len(subdirs))
+		for name := range subdirs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sort.Strings(subdirs[name])
+			reports = append(reports, subdirs[name])
+		}
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("❌ レポートパッケージにレポートが見つかりません: %s", archive)
+	}
+
+	manifest := &model.Manifest{Path: archive}
+	for i, entries := range reports {
+		kind := reportKinds[strings.ToLower(path.Ext(entries[0]))]
+		paths := make([]string, len(entries))
+		for j, entry := range entries {
+			paths[j] = ArchivePath(archive, entry)
+		}
+
+		var instance *model.XBRLInstance
+		var ixbrlFiles []string
+		switch kind {
+		case "inline":
+			// 文書セットの中の InlineXBRL 文書だけを1つのインスタンスにする
+			for _, p := range paths {
+				if reportKinds[strings.ToLower(path.Ext(p))] == "inline" {
+					ixbrlFiles = append(ixbrlFiles, p)
+				}
+			}
+			instance, err = ParseInlineXBRLs(ixbrlFiles, ixbrlFiles[0])
+		case "xbrl-json":
+			instance, err = ParseXBRLJSON(paths[0])
+		default:
+			instance, err = ParseInstance(paths[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("❌ レポートのパースに失敗: %s: %v", entries[0], err)
+		}
+
+		manifest.List.Instances = append(manifest.List.Instances, model.Instance{
+			ID:                fmt.Sprintf("report%d", i+1),
+			Type:              kind,
+			PreferredFilename: strings.TrimPrefix(entries[0], root),
+			IXBRLFiles:        ixbrlFiles,
+		})
+		manifest.List.XBRLInstances = append(manifest.List.XBRLInstances, instance)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,783 @@
+package parser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Inline XBRL Transformation Registry の名前空間
+const (
+	TransformationRegistryV1  = "http://www.xbrl.org/inlineXBRL/transformation/2010-04-20"
+	TransformationRegistryV2  = "http://www.xbrl.org/inlineXBRL/transformation/2011-07-31"
+	TransformationRegistryV3  = "http://www.xbrl.org/inlineXBRL/transformation/2015-02-26"
+	TransformationRegistryV4  = "http://www.xbrl.org/inlineXBRL/transformation/2020-02-12"
+	TransformationRegistryV5  = "http://www.xbrl.org/inlineXBRL/transformation/2022-02-16"
+	TransformationRegistrySEC = "http://www.sec.gov/inlineXBRL/transformation/2015-08-31"
+)
+
+type transformFunc func(string) (string, error)
+
+// 名前空間 → 変換名 → 変換処理
+var transformRegistry = map[string]map[string]transformFunc{}
+
+func registerTransforms(namespaces []string, names []string, fn transformFunc) {
+	for _, ns := range namespaces {
+		if transformRegistry[ns] == nil {
+			transformRegistry[ns] = map[string]transformFunc{}
+		}
+		for _, name := range names {
+			transformRegistry[ns][name] = fn
+		}
+	}
+}
+
+func init() {
+	v1 := []string{TransformationRegistryV1}
+	v23 := []string{TransformationRegistryV2, TransformationRegistryV3}
+	v3 := []string{TransformationRegistryV3}
+	v45 := []string{TransformationRegistryV4, TransformationRegistryV5}
+	v5 := []string{TransformationRegistryV5}
+	sec := []string{TransformationRegistrySEC}
+
+	// TRR v1
+	registerTransforms(v1, []string{"numcommadot", "numspacedot"}, numDotDecimal)
+	registerTransforms(v1, []string{"numdotcomma", "numspacecomma", "numcomma"}, numCommaDecimal)
+	registerTransforms(v1, []string{"numdash"}, zeroDash)
+	registerTransforms(v1, []string{"dateslashus", "datedotus"}, dateMonthDayYear)
+	registerTransforms(v1, []string{"dateslasheu", "datedoteu"}, dateDayMonthYear)
+	registerTransforms(v1, []string{"datelongus", "dateshortus"}, dateMonthNameDayYear("en"))
+	registerTransforms(v1, []string{"datelongeu", "dateshorteu", "datelonguk", "dateshortuk"}, dateDayMonthNameYear("en"))
+
+	// TRR v2, v3
+	registerTransforms(v23, []string{"numdotdecimal"}, numDotDecimal)
+	registerTransforms(v23, []string{"numcommadecimal"}, numCommaDecimal)
+	registerTransforms(v23, []string{"numunitdecimal"}, numUnitDecimal)
+	registerTransforms(v23, []string{"zerodash"}, zeroDash)
+	registerTransforms(v23, []string{"nocontent"}, fixedValue(""))
+	registerTransforms(v23, []string{"booleanfalse"}, fixedValue("false"))
+	registerTransforms(v23, []string{"booleantrue"}, fixedValue("true"))
+	registerTransforms(v23, []string{"datedaymonth"}, dateDayMonth)
+	registerTransforms(v23, []string{"datemonthday"}, dateMonthDay)
+	registerTransforms(v23, []string{"datedaymonthyear"}, dateDayMonthYear)
+	registerTransforms(v23, []string{"datemonthdayyear"}, dateMonthDayYear)
+	registerTransforms(v23, []string{"datedaymonthen"}, dateDayMonthName("en"))
+	registerTransforms(v23, []string{"datemonthdayen"}, dateMonthNameDay("en"))
+	registerTransforms(v23, []string{"datedaymonthyearen"}, dateDayMonthNameYear("en"))
+	registerTransforms(v23, []string{"datemonthdayyearen"}, dateMonthNameDayYear("en"))
+	registerTransforms(v23, []string{"datemonthyearen"}, dateMonthNameYear("en"))
+	registerTransforms(v23, []string{"dateyearmonthen"}, dateYearMonthName("en"))
+	registerTransforms(v23, []string{"dateyearmonthdaycjk"}, dateYearMonthDayCJK)
+	registerTransforms(v23, []string{"dateyearmonthcjk"}, dateYearMonthCJK)
+	registerTransforms(v23, []string{"dateerayearmonthdayjp"}, dateEraYearMonthDayJP)
+	registerTransforms(v23, []string{"dateerayearmonthjp"}, dateEraYearMonthJP)
+	registerTransforms(v3, []string{"datemonthyear"}, dateMonthYear)
+	registerTransforms(v3, []string{"dateyearmonthday"}, dateYearMonthDay)
+	registerTransforms(v3, []string{"numdotdecimalin"}, numDotDecimalIn)
+	registerTransforms(v3, []string{"numunitdecimalin"}, numUnitDecimalIn)
+	registerTransforms(v3, []string{"datedaymonthdk"}, dateDayMonthName("da"))
+	registerTransforms(v3, []string{"datedaymonthyeardk"}, dateDayMonthNameYear("da"))
+	registerTransforms(v3, []string{"datemonthyeardk"}, dateMonthNameYear("da"))
+	registerTransforms(v3, []string{"datedaymonthyearin"}, dateDayMonthNameYear("hi"))
+	registerTransforms(v3, []string{"datemonthyearin"}, dateMonthNameYear("hi"))
+
+	// TRR v4, v5
+	registerTransforms(v45, []string{"num-dot-decimal"}, numDotDecimal)
+	registerTransforms(v45, []string{"num-comma-decimal"}, numCommaDecimal)
+	registerTransforms(v45, []string{"num-unit-decimal"}, numUnitDecimal)
+	registerTransforms(v45, []string{"num-dot-decimal-in"}, numDotDecimalIn)
+	registerTransforms(v45, []string{"num-unit-decimal-in"}, numUnitDecimalIn)
+	registerTransforms(v45, []string{"fixed-zero"}, fixedValue("0"))
+	registerTransforms(v45, []string{"fixed-empty"}, fixedValue(""))
+	registerTransforms(v45, []string{"fixed-false"}, fixedValue("false"))
+	registerTransforms(v45, []string{"fixed-true"}, fixedValue("true"))
+	registerTransforms(v45, []string{"date-day-month"}, dateDayMonth)
+	registerTransforms(v45, []string{"date-month-day"}, dateMonthDay)
+	registerTransforms(v45, []string{"date-day-month-year"}, dateDayMonthYear)
+	registerTransforms(v45, []string{"date-month-day-year"}, dateMonthDayYear)
+	registerTransforms(v45, []string{"date-year-month-day"}, dateYearMonthDay)
+	registerTransforms(v45, []string{"date-month-year"}, dateMonthYear)
+	registerTransforms(v45, []string{"date-year-month"}, dateYearMonth)
+	registerTransforms(v45, []string{"date-monthname-day-en"}, dateMonthNameDay("en"))
+	registerTransforms(v45, []string{"date-monthname-day-year-en"}, dateMonthNameDayYear("en"))
+	registerTransforms(v45, []string{"date-year-monthname-en"}, dateYearMonthName("en"))
+	for _, lang := range monthNameLanguages {
+		if lang != "hi" {
+			registerTransforms(v45, []string{"date-day-monthname-" + lang}, dateDayMonthName(lang))
+		}
+		registerTransforms(v45, []string{"date-day-monthname-year-" + lang}, dateDayMonthNameYear(lang))
+		registerTransforms(v45, []string{"date-monthname-year-" + lang}, dateMonthNameYear(lang))
+	}
+	registerTransforms(v45, []string{"date-jpn-era-year-month-day"}, dateEraYearMonthDayJP)
+	registerTransforms(v45, []string{"date-jpn-era-year-month"}, dateEraYearMonthJP)
+
+	// TRR v5
+	registerTransforms(v5, []string{"num-dot-decimal-apos"}, numDotDecimalApos)
+	registerTransforms(v5, []string{"num-comma-decimal-apos"}, numCommaDecimalApos)
+
+	// SEC
+	registerTransforms(sec, []string{"numwordsen"}, numWordsEn)
+	registerTransforms(sec, []string{"numinf"}, fixedValue("INF"))
+	registerTransforms(sec, []string{"numneginf"}, fixedValue("-INF"))
+	registerTransforms(sec, []string{"numnan"}, fixedValue("NaN"))
+	registerTransforms(sec, []string{"boolballotbox"}, ballotBox("false", "true"))
+	registerTransforms(sec, []string{"yesnoballotbox"}, ballotBox("No", "Yes"))
+	registerTransforms(sec, []string{"duryear"}, durationYear)
+	registerTransforms(sec, []string{"durmonth"}, durationMonth)
+	registerTransforms(sec, []string{"durweek"}, durationUnit("D", 7))
+	registerTransforms(sec, []string{"durday"}, durationUnit("D", 1))
+	registerTransforms(sec, []string{"durhour"}, durationUnit("H", 1))
+	registerTransforms(sec, []string{"durwordsen"}, durationWordsEn)
+	registerTransforms(sec, []string{"stateprovnameen"}, nameToCode(stateProvinceCodes))
+	registerTransforms(sec, []string{"countrynameen"}, nameToCode(countryCodes))
+	registerTransforms(sec, []string{"exchnameen"}, nameToCode(exchangeCodes))
+	registerTransforms(sec, []string{"entityfilercategoryen"}, nameToCode(entityFilerCategories))
+}
+
+// ix:format で指定された変換を適用する
+func Transform(format xml.Name, value string) (string, error) {
+	names, ok := transformRegistry[format.Space]
+	if !ok {
+		return "", fmt.Errorf("未対応の変換ルール名前空間: %s", format.Space)
+	}
+	fn, ok := names[format.Local]
+	if !ok {
+		return "", fmt.Errorf("未対応の変換ルール: %s", format.Local)
+	}
+	result, err := fn(normalizeTransformInput(value))
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", format.Local, err)
+	}
+	return result, nil
+}
+
+// 前後の空白を除き、連続する空白を1つにまとめる
+func normalizeTransformInput(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+var fullWidthDigits = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+	"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+)
+
+// インドの表記で使われるデーヴァナーガリー数字
+var devanagariDigits = strings.NewReplacer(
+	"०", "0", "१", "1", "२", "2", "३", "3", "४", "4",
+	"५", "5", "६", "6", "७", "7", "८", "8", "९", "9",
+)
+
+func fixedValue(v string) transformFunc {
+	return func(string) (string, error) {
+		return v, nil
+	}
+}
+
+// /////////////////////////////////////////////////////////////
+// 数値
+// /////////////////////////////////////////////////////////////
+var (
+	numDotDecimalPattern   = regexp.MustCompile(`^[0-9]{1,3}([ \x{00A0}',]?[0-9]{3})*(\.[0-9]*)?$|^\.[0-9]+$|^[0-9]+(\.[0-9]*)?$`)
+	numCommaDecimalPattern = regexp.MustCompile(`^[0-9]{1,3}([ \x{00A0}'.]?[0-9]{3})*(,[0-9]*)?$|^,[0-9]+$|^[0-9]+(,[0-9]*)?$`)
+	numUnitDecimalPattern  = regexp.MustCompile(`^([0-9]+(?:[ \x{00A0}',.][0-9]{3})*)[^0-9]+([0-9]{1,2})[^0-9]*$`)
+	// インド式の桁区切り（例: 1,23,45,678）
+	numDotDecimalInPattern  = regexp.MustCompile(`^(?:[0-9]{1,2}[ \x{00A0},](?:[0-9]{2}[ \x{00A0},])*[0-9]{3}|[0-9]+)(\.[0-9]*)?$`)
+	numUnitDecimalInPattern = regexp.MustCompile(`^([0-9]{1,2}[ \x{00A0},](?:[0-9]{2}[ \x{00A0},])*[0-9]{3}|[0-9]+)[^0-9]+([0-9]{1,2})[^0-9]*$`)
+	zeroDashPattern         = regexp.MustCompile(`^[-\x{2010}-\x{2015}\x{2212}\x{FF0D}\x{30FC}]$`)
+	numGroupSeparators      = strings.NewReplacer(" ", "", "\u00a0", "", "'", "")
+	ordinalDayPattern       = regexp.MustCompile(`^([0-9]{1,4})(?:st|nd|rd|th|er|º)?$`)
+)
+
+func numDotDecimal(v string) (string, error) {
+	v = fullWidthDigits.Replace(v)
+	if !numDotDecimalPattern.MatchString(v) {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return trimNumber(strings.ReplaceAll(numGroupSeparators.Replace(v), ",", "")), nil
+}
+
+func numCommaDecimal(v string) (string, error) {
+	v = fullWidthDigits.Replace(v)
+	if !numCommaDecimalPattern.MatchString(v) {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	v = strings.ReplaceAll(numGroupSeparators.Replace(v), ".", "")
+	return trimNumber(strings.ReplaceAll(v, ",", ".")), nil
+}
+
+// アポストロフィ（’ を含む）を桁区切りとして許容する
+func numDotDecimalApos(v string) (string, error) {
+	return numDotDecimal(strings.ReplaceAll(v, "’", "'"))
+}
+
+func numCommaDecimalApos(v string) (string, error) {
+	return numCommaDecimal(strings.ReplaceAll(v, "’", "'"))
+}
+
+// 例: "1,23,45,678.90" → 12345678.90
+func numDotDecimalIn(v string) (string, error) {
+	v = devanagariDigits.Replace(fullWidthDigits.Replace(v))
+	if !numDotDecimalInPattern.MatchString(v) {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return trimNumber(strings.NewReplacer(" ", "", "\u00a0", "", ",", "").Replace(v)), nil
+}
+
+// 例: "5 dollars and 20 cents" → 5.20
+func numUnitDecimal(v string) (string, error) {
+	return unitDecimal(numUnitDecimalPattern, fullWidthDigits.Replace(v))
+}
+
+// 例: "1,00,000 rupees 50 paise" → 100000.50
+func numUnitDecimalIn(v string) (string, error) {
+	return unitDecimal(numUnitDecimalInPattern, devanagariDigits.Replace(fullWidthDigits.Replace(v)))
+}
+
+func unitDecimal(pattern *regexp.Regexp, v string) (string, error) {
+	m := pattern.FindStringSubmatch(v)
+	if m == nil {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	integer := strings.NewReplacer(" ", "", "\u00a0", "", "'", "", ",", "", ".", "").Replace(m[1])
+	fraction := m[2]
+	if len(fraction) == 1 {
+		fraction = "0" + fraction
+	}
+	return trimNumber(integer + "." + fraction), nil
+}
+
+func zeroDash(v string) (string, error) {
+	if !zeroDashPattern.MatchString(v) {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return "0", nil
+}
+
+// 先頭の余分な0と小数点のみの末尾を取り除く
+func trimNumber(v string) string {
+	if strings.HasPrefix(v, ".") {
+		v = "0" + v
+	}
+	v = strings.TrimSuffix(v, ".")
+	for len(v) > 1 && v[0] == '0' && v[1] != '.' {
+		v = v[1:]
+	}
+	return v
+}
+
+var numberWords = map[string]int64{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17,
+	"eighteen": 18, "nineteen": 19, "twenty": 20, "thirty": 30, "forty": 40,
+	"fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+var numberScales = map[string]int64{
+	"thousand": 1_000, "million": 1_000_000, "billion": 1_000_000_000, "trillion": 1_000_000_000_000,
+}
+
+// 英語の数詞を数値に変換する（例: "one hundred twenty-three" → 123）
+func numWordsEn(v string) (string, error) {
+	lower := strings.ToLower(v)
+	if lower == "no" || lower == "none" {
+		return "0", nil
+	}
+
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return r == ' ' || r == '-' || r == ','
+	})
+	if len(words) == 0 {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+
+	var total, current int64
+	for _, w := range words {
+		if w == "and" {
+			continue
+		}
+		if n, ok := numberWords[w]; ok {
+			current += n
+		} else if w == "hundred" {
+			current *= 100
+		} else if scale, ok := numberScales[w]; ok {
+			total += current * scale
+			current = 0
+		} else {
+			return "", fmt.Errorf("形式が一致しません: %s", v)
+		}
+	}
+	return strconv.FormatInt(total+current, 10), nil
+}
+
+// /////////////////////////////////////////////////////////////
+// 日付
+// /////////////////////////////////////////////////////////////
+var (
+	dateTwoPartsPattern   = regexp.MustCompile(`^([0-9]{1,4})[^0-9]+([0-9]{1,2})[^0-9]*$`)
+	dateMonthYearPattern  = regexp.MustCompile(`^([0-9]{1,2})[^0-9]+([0-9]{2}|[0-9]{4})[^0-9]*$`)
+	dateThreePartsPattern = regexp.MustCompile(`^([0-9]{1,4})[^0-9]+([0-9]{1,2})[^0-9]+([0-9]{1,4})[^0-9]*$`)
+	dateYearFirstPattern  = regexp.MustCompile(`^([0-9]{2}|[0-9]{4})[^0-9]+([0-9]{1,2})[^0-9]+([0-9]{1,2})[^0-9]*$`)
+)
+
+// 言語ごとの月名（1月から順に並べ、格変化などの別の語形は | で区切る）
+var monthNames = map[string][12]string{
+	"cs": {"leden|ledna", "únor|února", "březen|března", "duben|dubna", "květen|května", "červen|června", "červenec|července", "srpen|srpna", "září", "říjen|října", "listopad|listopadu", "prosinec|prosince"},
+	"da": {"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"},
+	"de": {"januar|jänner", "februar|feber", "märz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+	"en": {"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre|setiembre", "octubre", "noviembre", "diciembre"},
+	"fi": {"tammikuuta", "helmikuuta", "maaliskuuta", "huhtikuuta", "toukokuuta", "kesäkuuta", "heinäkuuta", "elokuuta", "syyskuuta", "lokakuuta", "marraskuuta", "joulukuuta"},
+	"fr": {"janvier", "février|fevrier", "mars", "avril", "mai", "juin", "juillet", "août|aout", "septembre", "octobre", "novembre", "décembre|decembre"},
+	"hi": {"जनवरी", "फरवरी|फ़रवरी", "मार्च", "अप्रैल", "मई", "जून", "जुलाई", "अगस्त", "सितंबर|सितम्बर", "अक्टूबर|अक्तूबर", "नवंबर|नवम्बर", "दिसंबर|दिसम्बर"},
+	"it": {"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+	"nl": {"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+	"no": {"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"},
+	"pl": {"styczeń|stycznia", "luty|lutego", "marzec|marca", "kwiecień|kwietnia", "maj|maja", "czerwiec|czerwca", "lipiec|lipca", "sierpień|sierpnia", "wrzesień|września", "październik|października", "listopad|listopada", "grudzień|grudnia"},
+	"pt": {"janeiro", "fevereiro", "março|marco", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+	"sv": {"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+}
+
+// 月名の変換を登録する言語（TRR v4 の言語コード）
+var monthNameLanguages = []string{"cs", "da", "de", "en", "es", "fi", "fr", "hi", "it", "nl", "no", "pl", "pt", "sv"}
+
+// 日付の中で読み飛ばす語（例: "31 de marzo de 2025"）
+var dateFillerWords = map[string]bool{"de": true, "del": true, "of": true}
+
+// 月名から月を求める
+// 語形と完全に一致するか、3文字以上で1つの月にだけ前方一致する省略形（例: "sept"）を受け付ける
+func lookupMonthName(lang, token string) (int, bool) {
+	found := 0
+	for i, forms := range monthNames[lang] {
+		for _, form := range strings.Split(forms, "|") {
+			if token == form {
+				return i + 1, true
+			}
+			if utf8.RuneCountInString(token) >= 3 && strings.HasPrefix(form, token) {
+				if found != 0 && found != i+1 {
+					return 0, false
+				}
+				found = i + 1
+			}
+		}
+	}
+	return found, found != 0
+}
+
+// TRR に従い、2桁の年は 00〜49 を2000年代、50〜99 を1900年代とみなす
+func parseYear(s string) (int, error) {
+	if len(s) != 2 && len(s) != 4 {
+		return 0, fmt.Errorf("年の形式が不正です: %s", s)
+	}
+	y, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(s) == 2 {
+		if y < 50 {
+			y += 2000
+		} else {
+			y += 1900
+		}
+	}
+	return y, nil
+}
+
+func formatDate(y, m, d int) (string, error) {
+	if m < 1 || m > 12 {
+		return "", fmt.Errorf("月が不正です: %d", m)
+	}
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if t.Day() != d {
+		return "", fmt.Errorf("日付が不正です: %04d-%02d-%02d", y, m, d)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", y, m, d), nil
+}
+
+func formatYearMonth(y, m int) (string, error) {
+	if m < 1 || m > 12 {
+		return "", fmt.Errorf("月が不正です: %d", m)
+	}
+	return fmt.Sprintf("%04d-%02d", y, m), nil
+}
+
+// xs:gMonthDay 形式（--MM-DD）
+func formatMonthDay(m, d int) (string, error) {
+	// うるう年で検証して2/29を許容する
+	if _, err := formatDate(2000, m, d); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("--%02d-%02d", m, d), nil
+}
+
+func dateParts(pattern *regexp.Regexp, v string) ([]string, error) {
+	m := pattern.FindStringSubmatch(fullWidthDigits.Replace(v))
+	if m == nil {
+		return nil, fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return m[1:], nil
+}
+
+func dateDayMonthYear(v string) (string, error) {
+	p, err := dateParts(dateThreePartsPattern, v)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseYear(p[2])
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[1])
+	d, _ := strconv.Atoi(p[0])
+	return formatDate(y, m, d)
+}
+
+func dateMonthDayYear(v string) (string, error) {
+	p, err := dateParts(dateThreePartsPattern, v)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseYear(p[2])
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[0])
+	d, _ := strconv.Atoi(p[1])
+	return formatDate(y, m, d)
+}
+
+func dateYearMonthDay(v string) (string, error) {
+	p, err := dateParts(dateYearFirstPattern, v)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseYear(p[0])
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[1])
+	d, _ := strconv.Atoi(p[2])
+	return formatDate(y, m, d)
+}
+
+func dateDayMonth(v string) (string, error) {
+	p, err := dateParts(dateTwoPartsPattern, v)
+	if err != nil {
+		return "", err
+	}
+	d, _ := strconv.Atoi(p[0])
+	m, _ := strconv.Atoi(p[1])
+	return formatMonthDay(m, d)
+}
+
+func dateMonthDay(v string) (string, error) {
+	p, err := dateParts(dateTwoPartsPattern, v)
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[0])
+	d, _ := strconv.Atoi(p[1])
+	return formatMonthDay(m, d)
+}
+
+func dateMonthYear(v string) (string, error) {
+	p, err := dateParts(dateMonthYearPattern, v)
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[0])
+	y, err := parseYear(p[1])
+	if err != nil {
+		return "", err
+	}
+	return formatYearMonth(y, m)
+}
+
+func dateYearMonth(v string) (string, error) {
+	p, err := dateParts(dateTwoPartsPattern, v)
+	if err != nil {
+		return "", err
+	}
+	y, err := parseYear(p[0])
+	if err != nil {
+		return "", err
+	}
+	m, _ := strconv.Atoi(p[1])
+	return formatYearMonth(y, m)
+}
+
+// 月名を含む日付を、数字と月の並びに分解する
+// 例: "31 March 2025" → ["31", "3", "2025"]
+func monthNameDateParts(lang, v string) ([]string, error) {
+	v = devanagariDigits.Replace(fullWidthDigits.Replace(v))
+	tokens := strings.FieldsFunc(strings.ToLower(v), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '.' || r == '-' || r == '/'
+	})
+	parts := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if m, ok := lookupMonthName(lang, t); ok {
+			parts = append(parts, strconv.Itoa(m))
+		} else if d := ordinalDayPattern.FindStringSubmatch(t); d != nil {
+			parts = append(parts, d[1])
+		} else if !dateFillerWords[t] {
+			return nil, fmt.Errorf("形式が一致しません: %s", v)
+		}
+	}
+	return parts, nil
+}
+
+func monthNameDate(lang string, n int, order func([]string) (string, error)) transformFunc {
+	return func(v string) (string, error) {
+		parts, err := monthNameDateParts(lang, v)
+		if err != nil {
+			return "", err
+		}
+		if len(parts) != n {
+			return "", fmt.Errorf("形式が一致しません: %s", v)
+		}
+		return order(parts)
+	}
+}
+
+func dateDayMonthName(lang string) transformFunc {
+	return monthNameDate(lang, 2, func(p []string) (string, error) {
+		d, _ := strconv.Atoi(p[0])
+		m, _ := strconv.Atoi(p[1])
+		return formatMonthDay(m, d)
+	})
+}
+
+func dateMonthNameDay(lang string) transformFunc {
+	return monthNameDate(lang, 2, func(p []string) (string, error) {
+		m, _ := strconv.Atoi(p[0])
+		d, _ := strconv.Atoi(p[1])
+		return formatMonthDay(m, d)
+	})
+}
+
+func dateDayMonthNameYear(lang string) transformFunc {
+	return monthNameDate(lang, 3, func(p []string) (string, error) {
+		d, _ := strconv.Atoi(p[0])
+		m, _ := strconv.Atoi(p[1])
+		y, err := parseYear(p[2])
+		if err != nil {
+			return "", err
+		}
+		return formatDate(y, m, d)
+	})
+}
+
+func dateMonthNameDayYear(lang string) transformFunc {
+	return monthNameDate(lang, 3, func(p []string) (string, error) {
+		m, _ := strconv.Atoi(p[0])
+		d, _ := strconv.Atoi(p[1])
+		y, err := parseYear(p[2])
+		if err != nil {
+			return "", err
+		}
+		return formatDate(y, m, d)
+	})
+}
+
+func dateMonthNameYear(lang string) transformFunc {
+	return monthNameDate(lang, 2, func(p []string) (string, error) {
+		m, _ := strconv.Atoi(p[0])
+		y, err := parseYear(p[1])
+		if err != nil {
+			return "", err
+		}
+		return formatYearMonth(y, m)
+	})
+}
+
+func dateYearMonthName(lang string) transformFunc {
+	return monthNameDate(lang, 2, func(p []string) (string, error) {
+		y, err := parseYear(p[0])
+		if err != nil {
+			return "", err
+		}
+		m, _ := strconv.Atoi(p[1])
+		return formatYearMonth(y, m)
+	})
+}
+
+func dateYearMonthDayCJK(v string) (string, error) {
+	s, err := jpDateToISO(v)
+	if err != nil {
+		return "", err
+	}
+	if len(s) != len("2006-01-02") {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return validateISODate(s)
+}
+
+func dateYearMonthCJK(v string) (string, error) {
+	s, err := jpDateToISO(v)
+	if err != nil {
+		return "", err
+	}
+	if len(s) != len("2006-01") {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return validateISODate(s)
+}
+
+func dateEraYearMonthDayJP(v string) (string, error) {
+	s, err := warekiToSeireki(v)
+	if err != nil {
+		return "", err
+	}
+	if len(s) != len("2006-01-02") {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return validateISODate(s)
+}
+
+func dateEraYearMonthJP(v string) (string, error) {
+	s, err := warekiToSeireki(v)
+	if err != nil {
+		return "", err
+	}
+	if len(s) != len("2006-01") {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return validateISODate(s)
+}
+
+// YYYY-MM-DD または YYYY-MM の範囲を検証する
+func validateISODate(s string) (string, error) {
+	parts := strings.Split(s, "-")
+	y, _ := strconv.Atoi(parts[0])
+	m, _ := strconv.Atoi(parts[1])
+	if len(parts) == 2 {
+		return formatYearMonth(y, m)
+	}
+	d, _ := strconv.Atoi(parts[2])
+	return formatDate(y, m, d)
+}
+
+// /////////////////////////////////////////////////////////////
+// SEC
+// /////////////////////////////////////////////////////////////
+func ballotBox(unchecked, checked string) transformFunc {
+	return func(v string) (string, error) {
+		switch v {
+		case "☐":
+			return unchecked, nil
+		case "☑", "☒":
+			return checked, nil
+		}
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+}
+
+// 名称をコードに変換する（大文字小文字・句読点・空白の違いは無視する）
+func nameToCode(table map[string]string) transformFunc {
+	normalized := make(map[string]string, len(table))
+	for name, code := range table {
+		normalized[nameKey(name)] = code
+	}
+	return func(v string) (string, error) {
+		if code, ok := normalized[nameKey(v)]; ok {
+			return code, nil
+		}
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+}
+
+var nameKeyReplacer = strings.NewReplacer(".", "", ",", "", "'", "", "’", "", "-", " ", "\u00a0", " ")
+
+func nameKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(nameKeyReplacer.Replace(name))), " ")
+}
+
+var durationNumberPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+func parseDurationNumber(v string) (float64, error) {
+	v = strings.ReplaceAll(v, ",", "")
+	if !durationNumberPattern.MatchString(v) {
+		return 0, fmt.Errorf("形式が一致しません: %s", v)
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+func durationYear(v string) (string, error) {
+	n, err := parseDurationNumber(v)
+	if err != nil {
+		return "", err
+	}
+	years := int(n)
+	return formatDuration(years, (n-float64(years))*12)
+}
+
+func durationMonth(v string) (string, error) {
+	n, err := parseDurationNumber(v)
+	if err != nil {
+		return "", err
+	}
+	return formatDuration(0, n)
+}
+
+// 年と月（小数部は日に換算）から xs:duration を作る
+func formatDuration(years int, months float64) (string, error) {
+	m := int(months)
+	days := int((months - float64(m)) * 30.4375)
+	var sb strings.Builder
+	sb.WriteString("P")
+	if years > 0 {
+		fmt.Fprintf(&sb, "%dY", years)
+	}
+	if m > 0 {
+		fmt.Fprintf(&sb, "%dM", m)
+	}
+	if days > 0 || sb.Len() == 1 {
+		fmt.Fprintf(&sb, "%dD", days)
+	}
+	return sb.String(), nil
+}
+
+func durationUnit(designator string, factor float64) transformFunc {
+	return func(v string) (string, error) {
+		n, err := parseDurationNumber(v)
+		if err != nil {
+			return "", err
+		}
+		n *= factor
+		value := strconv.FormatFloat(n, 'f', -1, 64)
+		if designator == "H" {
+			return "PT" + value + "H", nil
+		}
+		return "P" + value + designator, nil
+	}
+}
+
+var durationWordsPattern = regexp.MustCompile(`^(?:(.+?)\s+years?)?[\s,]*(?:(?:and\s+)?(.+?)\s+months?)?[\s,]*(?:(?:and\s+)?(.+?)\s+days?)?$`)
+
+// 例: "two years, three months" → P2Y3M
+func durationWordsEn(v string) (string, error) {
+	m := durationWordsPattern.FindStringSubmatch(strings.ToLower(v))
+	if m == nil || (m[1] == "" && m[2] == "" && m[3] == "") {
+		return "", fmt.Errorf("形式が一致しません: %s", v)
+	}
+	var sb strings.Builder
+	sb.WriteString("P")
+	for i, designator := range []string{"Y", "M", "D"} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := numWordsEn(m[i+1])
+		if err != nil {
+			n, err = numDotDecimal(m[i+1])
+			if err != nil {
+				return "", err
+			}
+		}
+		sb.WriteString(n + designator)
+	}
+	return sb.String(), nil
+}
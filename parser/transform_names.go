@@ -0,0 +1,117 @@
+package parser
+
+// SEC の変換ルール（stateprovnameen など）で使う名称とコードの対応表
+
+// 米国の州・特別区・海外領土とカナダの州・準州
+var stateProvinceCodes = map[string]string{
+	"Alabama": "AL", "Alaska": "AK", "Arizona": "AZ", "Arkansas": "AR", "California": "CA",
+	"Colorado": "CO", "Connecticut": "CT", "Delaware": "DE", "District of Columbia": "DC", "Florida": "FL",
+	"Georgia": "GA", "Hawaii": "HI", "Idaho": "ID", "Illinois": "IL", "Indiana": "IN",
+	"Iowa": "IA", "Kansas": "KS", "Kentucky": "KY", "Louisiana": "LA", "Maine": "ME",
+	"Maryland": "MD", "Massachusetts": "MA", "Michigan": "MI", "Minnesota": "MN", "Mississippi": "MS",
+	"Missouri": "MO", "Montana": "MT", "Nebraska": "NE", "Nevada": "NV", "New Hampshire": "NH",
+	"New Jersey": "NJ", "New Mexico": "NM", "New York": "NY", "North Carolina": "NC", "North Dakota": "ND",
+	"Ohio": "OH", "Oklahoma": "OK", "Oregon": "OR", "Pennsylvania": "PA", "Rhode Island": "RI",
+	"South Carolina": "SC", "South Dakota": "SD", "Tennessee": "TN", "Texas": "TX", "Utah": "UT",
+	"Vermont": "VT", "Virginia": "VA", "Washington": "WA", "West Virginia": "WV", "Wisconsin": "WI",
+	"Wyoming": "WY",
+
+	// 海外領土
+	"American Samoa": "AS", "Guam": "GU", "Northern Mariana Islands": "MP", "Puerto Rico": "PR",
+	"United States Virgin Islands": "VI", "U.S. Virgin Islands": "VI",
+
+	// カナダ
+	"Alberta": "AB", "British Columbia": "BC", "Manitoba": "MB", "New Brunswick": "NB",
+	"Newfoundland and Labrador": "NL", "Nova Scotia": "NS", "Northwest Territories": "NT", "Nunavut": "NU",
+	"Ontario": "ON", "Prince Edward Island": "PE", "Quebec": "QC", "Québec": "QC", "Saskatchewan": "SK",
+	"Yukon": "YT",
+}
+
+// ISO 3166-1 の国名と2文字コード
+var countryCodes = map[string]string{
+	"Afghanistan": "AF", "Åland Islands": "AX", "Albania": "AL", "Algeria": "DZ", "American Samoa": "AS",
+	"Andorra": "AD", "Angola": "AO", "Anguilla": "AI", "Antarctica": "AQ", "Antigua and Barbuda": "AG",
+	"Argentina": "AR", "Armenia": "AM", "Aruba": "AW", "Australia": "AU", "Austria": "AT",
+	"Azerbaijan": "AZ", "Bahamas": "BS", "Bahrain": "BH", "Bangladesh": "BD", "Barbados": "BB",
+	"Belarus": "BY", "Belgium": "BE", "Belize": "BZ", "Benin": "BJ", "Bermuda": "BM",
+	"Bhutan": "BT", "Bolivia": "BO", "Bonaire, Sint Eustatius and Saba": "BQ", "Bosnia and Herzegovina": "BA", "Botswana": "BW",
+	"Bouvet Island": "BV", "Brazil": "BR", "British Indian Ocean Territory": "IO", "Brunei Darussalam": "BN", "Brunei": "BN",
+	"Bulgaria": "BG", "Burkina Faso": "BF", "Burundi": "BI", "Cabo Verde": "CV", "Cape Verde": "CV",
+	"Cambodia": "KH", "Cameroon": "CM", "Canada": "CA", "Cayman Islands": "KY", "Central African Republic": "CF",
+	"Chad": "TD", "Chile": "CL", "China": "CN", "Christmas Island": "CX", "Cocos (Keeling) Islands": "CC",
+	"Colombia": "CO", "Comoros": "KM", "Congo": "CG", "Democratic Republic of the Congo": "CD", "Cook Islands": "CK",
+	"Costa Rica": "CR", "Côte d'Ivoire": "CI", "Ivory Coast": "CI", "Croatia": "HR", "Cuba": "CU",
+	"Curaçao": "CW", "Cyprus": "CY", "Czechia": "CZ", "Czech Republic": "CZ", "Denmark": "DK",
+	"Djibouti": "DJ", "Dominica": "DM", "Dominican Republic": "DO", "Ecuador": "EC", "Egypt": "EG",
+	"El Salvador": "SV", "Equatorial Guinea": "GQ", "Eritrea": "ER", "Estonia": "EE", "Eswatini": "SZ",
+	"Ethiopia": "ET", "Falkland Islands": "FK", "Faroe Islands": "FO", "Fiji": "FJ", "Finland": "FI",
+	"France": "FR", "French Guiana": "GF", "French Polynesia": "PF", "French Southern Territories": "TF", "Gabon": "GA",
+	"Gambia": "GM", "Georgia": "GE", "Germany": "DE", "Ghana": "GH", "Gibraltar": "GI",
+	"Greece": "GR", "Greenland": "GL", "Grenada": "GD", "Guadeloupe": "GP", "Guam": "GU",
+	"Guatemala": "GT", "Guernsey": "GG", "Guinea": "GN", "Guinea-Bissau": "GW", "Guyana": "GY",
+	"Haiti": "HT", "Heard Island and McDonald Islands": "HM", "Holy See": "VA", "Honduras": "HN", "Hong Kong": "HK",
+	"Hungary": "HU", "Iceland": "IS", "India": "IN", "Indonesia": "ID", "Iran": "IR",
+	"Iraq": "IQ", "Ireland": "IE", "Isle of Man": "IM", "Israel": "IL", "Italy": "IT",
+	"Jamaica": "JM", "Japan": "JP", "Jersey": "JE", "Jordan": "JO", "Kazakhstan": "KZ",
+	"Kenya": "KE", "Kiribati": "KI", "North Korea": "KP", "South Korea": "KR", "Republic of Korea": "KR",
+	"Korea": "KR", "Kuwait": "KW", "Kyrgyzstan": "KG", "Lao People's Democratic Republic": "LA", "Laos": "LA",
+	"Latvia": "LV", "Lebanon": "LB", "Lesotho": "LS", "Liberia": "LR", "Libya": "LY",
+	"Liechtenstein": "LI", "Lithuania": "LT", "Luxembourg": "LU", "Macao": "MO", "Macau": "MO",
+	"Madagascar": "MG", "Malawi": "MW", "Malaysia": "MY", "Maldives": "MV", "Mali": "ML",
+	"Malta": "MT", "Marshall Islands": "MH", "Martinique": "MQ", "Mauritania": "MR", "Mauritius": "MU",
+	"Mayotte": "YT", "Mexico": "MX", "Micronesia": "FM", "Moldova": "MD", "Monaco": "MC",
+	"Mongolia": "MN", "Montenegro": "ME", "Montserrat": "MS", "Morocco": "MA", "Mozambique": "MZ",
+	"Myanmar": "MM", "Namibia": "NA", "Nauru": "NR", "Nepal": "NP", "Netherlands": "NL",
+	"New Caledonia": "NC", "New Zealand": "NZ", "Nicaragua": "NI", "Niger": "NE", "Nigeria": "NG",
+	"Niue": "NU", "Norfolk Island": "NF", "North Macedonia": "MK", "Northern Mariana Islands": "MP", "Norway": "NO",
+	"Oman": "OM", "Pakistan": "PK", "Palau": "PW", "Palestine": "PS", "Panama": "PA",
+	"Papua New Guinea": "PG", "Paraguay": "PY", "Peru": "PE", "Philippines": "PH", "Pitcairn": "PN",
+	"Poland": "PL", "Portugal": "PT", "Puerto Rico": "PR", "Qatar": "QA", "Réunion": "RE",
+	"Romania": "RO", "Russian Federation": "RU", "Russia": "RU", "Rwanda": "RW", "Saint Barthélemy": "BL",
+	"Saint Helena, Ascension and Tristan da Cunha": "SH", "Saint Kitts and Nevis": "KN", "Saint Lucia": "LC", "Saint Martin": "MF", "Saint Pierre and Miquelon": "PM",
+	"Saint Vincent and the Grenadines": "VC", "Samoa": "WS", "San Marino": "SM", "Sao Tome and Principe": "ST", "Saudi Arabia": "SA",
+	"Senegal": "SN", "Serbia": "RS", "Seychelles": "SC", "Sierra Leone": "SL", "Singapore": "SG",
+	"Sint Maarten": "SX", "Slovakia": "SK", "Slovenia": "SI", "Solomon Islands": "SB", "Somalia": "SO",
+	"South Africa": "ZA", "South Georgia and the South Sandwich Islands": "GS", "South Sudan": "SS", "Spain": "ES", "Sri Lanka": "LK",
+	"Sudan": "SD", "Suriname": "SR", "Svalbard and Jan Mayen": "SJ", "Sweden": "SE", "Switzerland": "CH",
+	"Syrian Arab Republic": "SY", "Syria": "SY", "Taiwan": "TW", "Tajikistan": "TJ", "Tanzania": "TZ",
+	"Thailand": "TH", "Timor-Leste": "TL", "Togo": "TG", "Tokelau": "TK", "Tonga": "TO",
+	"Trinidad and Tobago": "TT", "Tunisia": "TN", "Türkiye": "TR", "Turkey": "TR", "Turkmenistan": "TM",
+	"Turks and Caicos Islands": "TC", "Tuvalu": "TV", "Uganda": "UG", "Ukraine": "UA", "United Arab Emirates": "AE",
+	"United Kingdom": "GB", "United States": "US", "United States of America": "US", "United States Minor Outlying Islands": "UM", "Uruguay": "UY",
+	"Uzbekistan": "UZ", "Vanuatu": "VU", "Venezuela": "VE", "Viet Nam": "VN", "Vietnam": "VN",
+	"British Virgin Islands": "VG", "U.S. Virgin Islands": "VI", "Wallis and Futuna": "WF", "Western Sahara": "EH", "Yemen": "YE",
+	"Zambia": "ZM", "Zimbabwe": "ZW",
+}
+
+// dei:SecurityExchangeName で使う取引所コード
+var exchangeCodes = map[string]string{
+	"BOX Exchange LLC": "BOX", "BOX Exchange": "BOX",
+	"Cboe BYX Exchange, Inc.": "CboeBYX", "Cboe BYX Exchange": "CboeBYX",
+	"Cboe BZX Exchange, Inc.": "CboeBZX", "Cboe BZX Exchange": "CboeBZX",
+	"Cboe C2 Exchange, Inc.": "C2", "Cboe C2 Exchange": "C2",
+	"Cboe EDGA Exchange, Inc.": "CboeEDGA", "Cboe EDGA Exchange": "CboeEDGA",
+	"Cboe EDGX Exchange, Inc.": "CboeEDGX", "Cboe EDGX Exchange": "CboeEDGX",
+	"Cboe Exchange, Inc.": "CBOE", "Cboe Exchange": "CBOE",
+	"Chicago Stock Exchange, Inc.": "CHX", "Chicago Stock Exchange": "CHX",
+	"Investors Exchange LLC": "IEX", "Investors Exchange": "IEX",
+	"Miami International Securities Exchange, LLC": "MIAX", "Miami International Securities Exchange": "MIAX",
+	"MIAX PEARL, LLC": "PEARL", "MIAX PEARL": "PEARL",
+	"Nasdaq BX, Inc.": "BX", "Nasdaq BX": "BX",
+	"Nasdaq GEMX, LLC": "GEMX", "Nasdaq GEMX": "GEMX",
+	"Nasdaq ISE, LLC": "ISE", "Nasdaq ISE": "ISE",
+	"Nasdaq MRX, LLC": "MRX", "Nasdaq MRX": "MRX",
+	"Nasdaq PHLX LLC": "PHLX", "Nasdaq PHLX": "PHLX",
+	"The Nasdaq Stock Market LLC": "NASDAQ", "Nasdaq Stock Market": "NASDAQ",
+	"New York Stock Exchange LLC": "NYSE", "New York Stock Exchange": "NYSE",
+	"NYSE American LLC": "NYSEAMER", "NYSE American": "NYSEAMER",
+	"NYSE Arca, Inc.": "NYSEArca", "NYSE Arca": "NYSEArca",
+	"NYSE National, Inc.": "NYSENAT", "NYSE National": "NYSENAT",
+}
+
+// dei:EntityFilerCategory の値
+var entityFilerCategories = map[string]string{
+	"Large Accelerated Filer": "Large Accelerated Filer",
+	"Accelerated Filer":       "Accelerated Filer",
+	"Non-accelerated Filer":   "Non-accelerated Filer",
+}
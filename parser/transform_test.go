@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+type transformCase struct {
+	name  string
+	input string
+	want  string
+}
+
+// 正しく変換できない入力は want を "!" にする
+func testTransforms(t *testing.T, namespace string, cases []transformCase) {
+	t.Helper()
+	for _, c := range cases {
+		got, err := Transform(xml.Name{Space: namespace, Local: c.name}, c.input)
+		if c.want == "!" {
+			if err == nil {
+				t.Errorf("%s(%q) = %q, want an error", c.name, c.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s(%q): %v", c.name, c.input, err)
+		} else if got != c.want {
+			t.Errorf("%s(%q) = %q, want %q", c.name, c.input, got, c.want)
+		}
+	}
+}
+
+func TestTransformV1(t *testing.T) {
+	testTransforms(t, TransformationRegistryV1, []transformCase{
+		{"numcommadot", "1,234,567.89", "1234567.89"},
+		{"numdotcomma", "1.234.567,89", "1234567.89"},
+		{"numspacedot", "1 234.5", "1234.5"},
+		{"numdash", "-", "0"},
+		{"dateslashus", "12/31/2024", "2024-12-31"},
+		{"dateslasheu", "31/12/2024", "2024-12-31"},
+		{"datelongus", "March 31, 2025", "2025-03-31"},
+		{"datelonguk", "31 March 2025", "2025-03-31"},
+		{"dateslashus", "02/30/2024", "!"},
+		{"numdotdecimal", "1.5", "!"},
+	})
+}
+
+func TestTransformV2(t *testing.T) {
+	testTransforms(t, TransformationRegistryV2, []transformCase{
+		{"numdotdecimal", "1,234.50", "1234.50"},
+		{"numcommadecimal", "1.234,5", "1234.5"},
+		{"numunitdecimal", "5 dollars and 20 cents", "5.20"},
+		{"numunitdecimal", "5 dollars 2 cents", "5.02"},
+		{"zerodash", "—", "0"},
+		{"nocontent", "anything", ""},
+		{"booleantrue", "yes", "true"},
+		{"datedaymonth", "31/12", "--12-31"},
+		{"datemonthday", "2/29", "--02-29"},
+		{"datemonthdayyear", "12/31/99", "1999-12-31"},
+		{"datedaymonthyearen", "1st January 2024", "2024-01-01"},
+		{"datemonthdayyearen", "Sept. 30, 24", "2024-09-30"},
+		{"datemonthyearen", "Dec 2024", "2024-12"},
+		{"dateyearmonthen", "2024 June", "2024-06"},
+		{"dateyearmonthdaycjk", "2025年3月31日", "2025-03-31"},
+		{"dateerayearmonthdayjp", "令和7年3月31日", "2025-03-31"},
+		{"dateerayearmonthjp", "平成元年5月", "1989-05"},
+		{"numdotdecimal", "1,23.4", "!"},
+		{"datemonthyear", "12/2024", "!"},
+	})
+}
+
+func TestTransformV3(t *testing.T) {
+	testTransforms(t, TransformationRegistryV3, []transformCase{
+		{"numdotdecimal", "1,234.50", "1234.50"},
+		{"datemonthyear", "12/2024", "2024-12"},
+		{"dateyearmonthday", "2024-12-31", "2024-12-31"},
+		{"numdotdecimalin", "1,23,45,678.90", "12345678.90"},
+		{"numdotdecimalin", "१,००,०००", "100000"},
+		{"numdotdecimalin", "123,456", "!"},
+		{"numunitdecimalin", "1,00,000 rupees 50 paise", "100000.50"},
+		{"datedaymonthdk", "31. marts", "--03-31"},
+		{"datedaymonthyeardk", "1. dec. 2024", "2024-12-01"},
+		{"datemonthyeardk", "maj 2025", "2025-05"},
+		{"datedaymonthyearin", "२० जनवरी २०१५", "2015-01-20"},
+		{"datemonthyearin", "सितम्बर 2024", "2024-09"},
+		{"datedaymonthyeardk", "31. march 2025", "!"},
+	})
+}
+
+func TestTransformV4(t *testing.T) {
+	testTransforms(t, TransformationRegistryV4, []transformCase{
+		{"num-dot-decimal", "1,234,567.8", "1234567.8"},
+		{"num-comma-decimal", "1 234,5", "1234.5"},
+		{"num-dot-decimal-in", "12,34,567", "1234567"},
+		{"fixed-zero", "-", "0"},
+		{"date-day-month-year", "31.12.2024", "2024-12-31"},
+		{"date-year-month", "2024/12", "2024-12"},
+		{"date-day-monthname-en", "31 Dec", "--12-31"},
+		{"date-day-monthname-year-de", "31. März 2025", "2025-03-31"},
+		{"date-day-monthname-year-de", "1. Jänner 2025", "2025-01-01"},
+		{"date-day-monthname-year-fr", "1er juillet 2025", "2025-07-01"},
+		{"date-day-monthname-year-fr", "14 juil. 2025", "2025-07-14"},
+		{"date-day-monthname-year-es", "31 de marzo de 2025", "2025-03-31"},
+		{"date-day-monthname-year-pl", "5 września 2025", "2025-09-05"},
+		{"date-day-monthname-year-cs", "1. června 2025", "2025-06-01"},
+		{"date-day-monthname-year-fi", "30. kesäkuuta 2025", "2025-06-30"},
+		{"date-day-monthname-year-nl", "1 mei 2025", "2025-05-01"},
+		{"date-day-monthname-it", "25 dicembre", "--12-25"},
+		{"date-monthname-year-sv", "augusti 2025", "2025-08"},
+		{"date-monthname-year-pt", "março 2025", "2025-03"},
+		{"date-monthname-year-no", "desember 2025", "2025-12"},
+		{"date-monthname-year-hi", "मार्च 2025", "2025-03"},
+		{"date-jpn-era-year-month-day", "令和7年3月31日", "2025-03-31"},
+		// "jui" は juin と juillet のどちらにも当てはまる
+		{"date-day-monthname-year-fr", "14 jui 2025", "!"},
+		// "červen" は完全一致を優先し、červenec にはしない
+		{"date-monthname-year-cs", "červen 2025", "2025-06"},
+		{"num-dot-decimal-apos", "1'234.5", "!"},
+	})
+}
+
+func TestTransformV5(t *testing.T) {
+	testTransforms(t, TransformationRegistryV5, []transformCase{
+		{"num-dot-decimal", "1,234.5", "1234.5"},
+		{"num-dot-decimal-apos", "1’234’567.89", "1234567.89"},
+		{"num-dot-decimal-apos", "1'234.5", "1234.5"},
+		{"num-comma-decimal-apos", "1’234,5", "1234.5"},
+		{"date-day-monthname-year-da", "31. december 2024", "2024-12-31"},
+		{"date-monthname-day-year-en", "December 31, 2024", "2024-12-31"},
+		{"num-unit-decimal-in", "12,345 rupees 5 paise", "12345.05"},
+		{"num-dot-decimal-apos", "1’23’4.5", "!"},
+	})
+}
+
+func TestTransformSEC(t *testing.T) {
+	testTransforms(t, TransformationRegistrySEC, []transformCase{
+		{"numwordsen", "one hundred twenty-three", "123"},
+		{"numwordsen", "None", "0"},
+		{"boolballotbox", "☒", "true"},
+		{"duryear", "2.5", "P2Y6M"},
+		{"durwordsen", "two years, three months", "P2Y3M"},
+		{"stateprovnameen", "California", "CA"},
+		{"stateprovnameen", "british  columbia", "BC"},
+		{"stateprovnameen", "District of Columbia", "DC"},
+		{"countrynameen", "Japan", "JP"},
+		{"countrynameen", "united kingdom", "GB"},
+		{"countrynameen", "Guinea Bissau", "GW"},
+		{"exchnameen", "New York Stock Exchange", "NYSE"},
+		{"exchnameen", "The Nasdaq Stock Market LLC", "NASDAQ"},
+		{"exchnameen", "NYSE Arca, Inc.", "NYSEArca"},
+		{"entityfilercategoryen", "large accelerated filer", "Large Accelerated Filer"},
+		{"entityfilercategoryen", "Non-Accelerated Filer", "Non-accelerated Filer"},
+		{"entityfilercategoryen", "Non Accelerated Filer", "Non-accelerated Filer"},
+		{"stateprovnameen", "Atlantis", "!"},
+		{"countrynameen", "Narnia", "!"},
+	})
+}
+
+func TestParseYearCentury(t *testing.T) {
+	cases := map[string]int{"00": 2000, "24": 2024, "49": 2049, "50": 1950, "99": 1999, "1999": 1999, "2100": 2100}
+	for input, want := range cases {
+		if got, err := parseYear(input); err != nil || got != want {
+			t.Errorf("parseYear(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"1", "199", "20245"} {
+		if _, err := parseYear(input); err == nil {
+			t.Errorf("parseYear(%q): want an error", input)
+		}
+	}
+}
+
+func TestTransformUnknown(t *testing.T) {
+	if _, err := Transform(xml.Name{Space: "http://example.com/unknown", Local: "numdotdecimal"}, "1"); err == nil {
+		t.Error("want an error for an unknown registry namespace")
+	}
+	if _, err := Transform(xml.Name{Space: TransformationRegistryV4, Local: "numdotdecimal"}, "1"); err == nil {
+		t.Error("want an error for a v2 name in the v4 namespace")
+	}
+}
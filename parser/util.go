@@ -5,7 +5,6 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -20,35 +19,25 @@ func IsRemoteFile(path string) bool {
 func GetXMLReader(filename string) (*bytes.Reader, error) {
 	var data []byte
 
-	// EDINETのタクソノミはローカルのキャッシュパスから取得するため置き換える
+	// 📦 タクソノミパッケージのカタログに従って、パッケージ内のファイルに置き換える
 	if IsRemoteFile(filename) {
-		if strings.Contains(filename, "http://disclosure.edinet-fsa.go.jp/taxonomy/") {
-			base := os.Getenv("EDINET_TAXONOMY_DIR")
-			if base == "" {
-				base = "/app/taxonomy/all/taxonomy/" // デフォルト値
-			}
-			// 📂 ローカルファイルが存在する場合は、そちらを優先
-			_cachedfilePath := strings.Replace(filename, "http://disclosure.edinet-fsa.go.jp/taxonomy/", base, 1)
-			if _, err := os.Stat(_cachedfilePath); err == nil {
-				filename = _cachedfilePath
-			}
-		}
+		filename = rewriteURL(filename)
 	}
 
-	// 🌐 リモート URL の場合
-	if IsRemoteFile(filename) {
-		resp, err := http.Get(filename)
+	// 🗜️ ZIPファイル内のファイルの場合
+	if _, _, ok := splitArchivePath(filename); ok {
+		b, err := readArchiveFile(filename)
 		if err != nil {
-			return nil, fmt.Errorf("❌ XML取得失敗: %s", err)
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("❌ HTTPレスポンスエラー: %d %s", resp.StatusCode, filename)
+			return nil, err
 		}
-		data, err = io.ReadAll(resp.Body)
+		data = b
+	} else if IsRemoteFile(filename) {
+		// 🌐 リモート URL の場合（キャッシュを経由する）
+		b, err := fetchRemote(filename)
 		if err != nil {
-			return nil, fmt.Errorf("❌ HTTPレスポンスのデータ読み込み失敗: %s", err)
+			return nil, err
 		}
+		data = b
 	} else {
 		file, err := os.Open(filename)
 		if err != nil {
@@ -84,6 +73,11 @@ func ResolveHref(baseFilename, href string) string {
 		return newURLParsed.String()
 	}
 
+	// 🗜️ ZIPファイル内の相対パス処理
+	if _, _, ok := splitArchivePath(baseFilename); ok {
+		return resolveArchiveHref(baseFilename, href)
+	}
+
 	// 📂 ローカルファイルの相対パス処理
 	return filepath.Join(filepath.Dir(baseFilename), href)
 }
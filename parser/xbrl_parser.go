@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"encoding/xml"
 	"fmt"
 	"maps"
 	"strings"
@@ -62,7 +63,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			if strings.Contains(linkbaseRef.Role, "labelLinkbaseRef") {
 				linkbase, err := ParseXML[model.LabelLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ 名称リンクベースパースエラー: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "名称リンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -77,7 +78,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			} else if strings.Contains(linkbaseRef.Role, "referenceLinkbaseRef") {
 				linkbase, err := ParseXML[model.ReferenceLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ 参照リンクベースパースエラー: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "参照リンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -92,7 +93,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			} else if strings.Contains(linkbaseRef.Role, "presentationLinkbaseRef") {
 				linkbase, err := ParseXML[model.PresentationLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ 表示リンクベースパースエラー: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "表示リンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -102,7 +103,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			} else if strings.Contains(linkbaseRef.Role, "definitionLinkbaseRef") {
 				linkbase, err := ParseXML[model.DefinitionLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ 定義リンクベースパースエラー: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "定義リンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -112,7 +113,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			} else if strings.Contains(linkbaseRef.Role, "calculationLinkbaseRef") {
 				linkbase, err := ParseXML[model.CalculationLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ 計算リンクベースパースエラー: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "計算リンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -122,7 +123,7 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 			} else if linkbaseRef.Role == "" {
 				linkbase, err := ParseXML[model.GenericLinkBase](href)
 				if err != nil {
-					fmt.Printf("❌ ジェネリックリンクベースパース: %s", err)
+					Report(model.SeverityError, model.DiagLinkbaseParse, href, "linkbaseRef", "ジェネリックリンクベースを読み込めません: %v", err)
 					return
 				}
 				linkbase.Path = href
@@ -149,15 +150,16 @@ func ParseSchema(filename string, visited map[string]bool) (*model.XBRLSchema, e
 
 			importPath := ResolveHref(filename, imp.SchemaLoc)
 
-			// 🔥 XBRL標準スキーマならスキップ
-			if IsStandardXBRLSchema(importPath) {
-				return
-			}
-
 			// 🚀 スレッドローカルな `visitedCopy` を使って循環をチェック
+			// XBRL標準スキーマも他のリモートのファイルと同じくキャッシュを経由して読む
 			importedSchema, err := ParseSchema(importPath, visitedCopy)
 			if err != nil {
-				fmt.Printf("⚠️ インポートスキーマのパースに失敗: %s\n", err)
+				// 標準スキーマには提出者の関係が無いので、読めなくても警告にとどめる
+				severity := model.SeverityError
+				if IsStandardXBRLSchema(importPath) {
+					severity = model.SeverityWarning
+				}
+				Report(severity, model.DiagSchemaImport, filename, "import", "インポートスキーマのパースに失敗: %v", err)
 				return
 			}
 
@@ -180,6 +182,12 @@ func ParseInstance(instanceFile string) (*model.XBRLInstance, error) {
 	}
 	xbrlInstance.Path = instanceFile
 
+	// 単位の measure をルート要素の名前空間宣言で解決する
+	xbrlInstance.Namespaces = namespacesFromAttrs(xbrlInstance.Attrs)
+	for i := range xbrlInstance.Units {
+		resolveUnitMeasures(&xbrlInstance.Units[i], xbrlInstance.Namespaces)
+	}
+
 	// スキーマファイルの取得
 	if xbrlInstance.SchemaRefs.Href == "" {
 		return nil, fmt.Errorf("❌ スキーマファイルが見つかりません")
@@ -198,3 +206,35 @@ func ParseInstance(instanceFile string) (*model.XBRLInstance, error) {
 	xbrlInstance.SchemaRefs.Schema = schema
 	return xbrlInstance, nil
 }
+
+// 要素の属性から名前空間宣言を取り出す
+func namespacesFromAttrs(attrs []xml.Attr) map[string]string {
+	nsMap := make(map[string]string)
+	for _, attr := range attrs {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			nsMap["(default)"] = attr.Value
+		} else if attr.Name.Space == "xmlns" {
+			nsMap[attr.Name.Local] = attr.Value
+		}
+	}
+	return nsMap
+}
+
+// 単位の measure（QName）を名前空間付きの名前に解決する
+func resolveUnitMeasures(unit *model.Unit, nsMap map[string]string) {
+	numerator := unit.Measures
+	var denominator []string
+	if len(unit.Divide.Numerator) > 0 {
+		numerator = unit.Divide.Numerator
+		denominator = unit.Divide.Denominator
+	}
+
+	unit.Numerator = nil
+	for _, m := range numerator {
+		unit.Numerator = append(unit.Numerator, ResolveXMLName(strings.TrimSpace(m), nsMap))
+	}
+	unit.Denominator = nil
+	for _, m := range denominator {
+		unit.Denominator = append(unit.Denominator, ResolveXMLName(strings.TrimSpace(m), nsMap))
+	}
+}
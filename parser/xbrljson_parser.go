@@ -0,0 +1,411 @@
+package parser
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"thermal/model"
+	"time"
+)
+
+const (
+	xbrlJSONDocumentType = "https://xbrl.org/2021/xbrl-json"
+	xbrlJSONNoteConcept  = "xbrl:note"
+	defaultLinkGroup     = "_"
+)
+
+// xBRL-JSON の標準のリンク種別
+var xbrlJSONLinkTypes = map[string]string{
+	"footnote":        factFootnoteArcRole,
+	"explanatoryFact": "http://www.xbrl.org/2009/arcrole/fact-explanatoryFact",
+}
+
+// 数値の型（ローカル名）。型が分からない場合は decimals の有無で判断する
+var numericItemTypes = []string{
+	"monetaryItemType", "decimalItemType", "sharesItemType", "pureItemType", "perShareItemType",
+	"integerItemType", "nonNegativeIntegerItemType", "positiveIntegerItemType", "floatItemType", "doubleItemType",
+	"percentItemType", "areaItemType", "volumeItemType", "massItemType", "lengthItemType",
+}
+
+type xbrlJSONDocument struct {
+	DocumentInfo struct {
+		DocumentType string            `json:"documentType"`
+		Namespaces   map[string]string `json:"namespaces"`
+		LinkTypes    map[string]string `json:"linkTypes"`
+		LinkGroups   map[string]string `json:"linkGroups"`
+		Taxonomy     []string          `json:"taxonomy"`
+	} `json:"documentInfo"`
+	Facts map[string]xbrlJSONFact `json:"facts"`
+}
+
+type xbrlJSONFact struct {
+	Value      *string                        `json:"value"`
+	Decimals   *int                           `json:"decimals"`
+	Dimensions map[string]string              `json:"dimensions"`
+	Links      map[string]map[string][]string `json:"links"`
+}
+
+// xBRL-JSON の組み立て中の状態
+type xbrlJSONLoader struct {
+	instance *model.XBRLInstance
+	elements map[xml.Name]*model.XMLElement
+	ids      map[string]*model.XMLElement // スキーマのパス#id → 要素
+	contexts map[string]string            // コンテキストのキー → ID
+	units    map[string]string            // 単位の表記 → ID
+}
+
+// xBRL-JSON の文書を読み込み、XBRLインスタンスと同じ形にする
+// コンテキストと単位はファクトのディメンションの組合せごとに作り、ディメンションは segment に置く
+func ParseXBRLJSON(filename string) (*model.XBRLInstance, error) {
+	reader, err := GetXMLReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc xbrlJSONDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("❌ xBRL-JSON のパースに失敗:%v", err)
+	}
+	if doc.DocumentInfo.DocumentType != xbrlJSONDocumentType {
+		return nil, fmt.Errorf("❌ 未対応の文書種別です: %s", doc.DocumentInfo.DocumentType)
+	}
+	if len(doc.DocumentInfo.Taxonomy) == 0 {
+		return nil, fmt.Errorf("❌ スキーマファイルが見つかりません")
+	}
+
+	instance := &model.XBRLInstance{
+		Path:       filename,
+		SchemaRefs: model.SchemaRef{Href: doc.DocumentInfo.Taxonomy[0]},
+		Namespaces: make(map[string]string),
+	}
+	for prefix, uri := range doc.DocumentInfo.Namespaces {
+		instance.Namespaces[prefix] = uri
+	}
+
+	visited := make(map[string]bool)
+	schema, err := ParseSchema(ResolveHref(filename, instance.SchemaRefs.Href), visited)
+	if err != nil {
+		return nil, fmt.Errorf("❌ スキーマのパースに失敗:%v", err)
+	}
+	instance.SchemaRefs.Schema = schema
+
+	l := &xbrlJSONLoader{
+		instance: instance,
+		elements: make(map[xml.Name]*model.XMLElement),
+		ids:      make(map[string]*model.XMLElement),
+		contexts: make(map[string]string),
+		units:    make(map[string]string),
+	}
+	l.collectElements(schema, make(map[string]bool))
+
+	// ファクトはIDの順に並べる（JSONのオブジェクトには順序が無いため）
+	ids := make([]string, 0, len(doc.Facts))
+	for id := range doc.Facts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	notes := make(map[string]model.Footnote)
+	for _, id := range ids {
+		fact := doc.Facts[id]
+		if fact.Dimensions["concept"] == xbrlJSONNoteConcept {
+			value := ""
+			if fact.Value != nil {
+				value = *fact.Value
+			}
+			notes[id] = model.Footnote{
+				Label: id,
+				ID:    id,
+				Role:  defaultFootnoteRole,
+				Lang:  fact.Dimensions["language"],
+				Value: value,
+			}
+			continue
+		}
+		if err := l.addFact(id, fact); err != nil {
+			return nil, err
+		}
+	}
+
+	l.addFootnoteLinks(&doc, ids, notes)
+	return instance, nil
+}
+
+// DTSの要素を、名前と「スキーマのパス#id」で引けるようにする
+func (l *xbrlJSONLoader) collectElements(schema *model.XBRLSchema, visited map[string]bool) {
+	if schema == nil || visited[schema.Path] {
+		return
+	}
+	visited[schema.Path] = true
+	for i := range schema.Elements {
+		elem := &schema.Elements[i]
+		l.elements[xml.Name{Space: schema.TargetNS, Local: elem.Name}] = elem
+		if elem.Id != "" {
+			l.ids[schema.Path+"#"+elem.Id] = elem
+		}
+	}
+	for _, imp := range schema.Imports {
+		l.collectElements(imp.Schema, visited)
+	}
+}
+
+// 名前空間URIのプレフィックス。無ければ追加する
+func (l *xbrlJSONLoader) prefix(uri string) string {
+	for prefix, u := range l.instance.Namespaces {
+		if u == uri {
+			return prefix
+		}
+	}
+	prefix := "ns"
+	for i := 1; ; i++ {
+		if _, ok := l.instance.Namespaces[prefix]; !ok {
+			break
+		}
+		prefix = fmt.Sprintf("ns%d", i)
+	}
+	l.instance.Namespaces[prefix] = uri
+	return prefix
+}
+
+func (l *xbrlJSONLoader) addFact(id string, f xbrlJSONFact) error {
+	name := ResolveXMLName(f.Dimensions["concept"], l.instance.Namespaces)
+	if name.Space == "" {
+		return fmt.Errorf("❌ 要素の名前空間が見つかりません: %s (fact %s)", f.Dimensions["concept"], id)
+	}
+	elem := l.elements[name]
+
+	contextRef, err := l.context(f.Dimensions)
+	if err != nil {
+		return fmt.Errorf("%v (fact %s)", err, id)
+	}
+
+	fact := model.Fact{
+		XMLName:    name,
+		ID:         id,
+		ContextRef: contextRef,
+		Lang:       f.Dimensions["language"],
+	}
+	if f.Value == nil {
+		fact.Nil = "true"
+	} else {
+		fact.Value = *f.Value
+	}
+
+	// 数値のファクトには単位と精度を付ける（xbrli:pure の単位は省略されている）
+	numeric := f.Decimals != nil || f.Dimensions["unit"] != ""
+	if elem != nil {
+		local := elem.Type[strings.Index(elem.Type, ":")+1:]
+		for _, t := range numericItemTypes {
+			if local == t {
+				numeric = true
+			}
+		}
+	}
+	if numeric {
+		unit := f.Dimensions["unit"]
+		if unit == "" {
+			unit = "xbrli:pure"
+		}
+		fact.UnitRef = l.unit(unit)
+		if f.Value != nil {
+			fact.Decimals = "INF"
+			if f.Decimals != nil {
+				fact.Decimals = strconv.Itoa(*f.Decimals)
+			}
+		}
+	}
+
+	l.instance.Facts = append(l.instance.Facts, fact)
+	return nil
+}
+
+// entity・period・タクソノミ定義ディメンションからコンテキストを探し、無ければ作る
+func (l *xbrlJSONLoader) context(dims map[string]string) (string, error) {
+	var names []string
+	for name := range dims {
+		switch name {
+		case "concept", "entity", "period", "unit", "language", "noteId":
+		default:
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	key := dims["entity"] + "\x00" + dims["period"]
+	for _, name := range names {
+		key += "\x00" + name + "=" + dims[name]
+	}
+	if id, ok := l.contexts[key]; ok {
+		return id, nil
+	}
+
+	entity := dims["entity"]
+	i := strings.Index(entity, ":")
+	if i < 0 {
+		return "", fmt.Errorf("❌ entity が不正です: %s", entity)
+	}
+	scheme, ok := l.instance.Namespaces[entity[:i]]
+	if !ok {
+		return "", fmt.Errorf("❌ entity のスキームが見つかりません: %s", entity)
+	}
+
+	context := model.Context{
+		ID: fmt.Sprintf("c%d", len(l.contexts)+1),
+		Entity: model.Entity{
+			Identifier: model.Identifier{Scheme: scheme, Value: entity[i+1:]},
+		},
+		Period: xbrlPeriod(dims["period"]),
+	}
+	for _, name := range names {
+		if typed, ok := l.typedMember(name, dims[name]); ok {
+			context.Entity.Segment.TypedMembers = append(context.Entity.Segment.TypedMembers, model.TypedMember{Dimension: name, Value: typed})
+		} else {
+			context.Entity.Segment.Members = append(context.Entity.Segment.Members, model.Member{Dimension: name, Value: dims[name]})
+		}
+	}
+
+	l.contexts[key] = context.ID
+	l.instance.Contexts = append(l.instance.Contexts, context)
+	return context.ID, nil
+}
+
+// 型付きディメンションなら、メンバーのXML（ドメイン要素）を返す
+func (l *xbrlJSONLoader) typedMember(dimension, value string) (string, bool) {
+	elem, ok := l.elements[ResolveXMLName(dimension, l.instance.Namespaces)]
+	if !ok || elem.TypedDomainRef == "" {
+		return "", false
+	}
+
+	ref := elem.TypedDomainRef
+	if ref[0] == '#' {
+		ref = elem.Schema.Path + ref
+	} else {
+		ref = ResolveHref(elem.Schema.Path, ref)
+	}
+	domain, ok := l.ids[ref]
+	if !ok {
+		return "", false
+	}
+
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	name := l.prefix(domain.Schema.TargetNS) + ":" + domain.Name
+	return "<" + name + ">" + escaped.String() + "</" + name + ">", true
+}
+
+// 単位の表記（例: iso4217:JPY、iso4217:JPY/xbrli:shares、(a*b)/c）から単位を探し、無ければ作る
+func (l *xbrlJSONLoader) unit(value string) string {
+	if id, ok := l.units[value]; ok {
+		return id
+	}
+
+	measures := func(s string) []string {
+		return strings.Split(strings.Trim(s, "()"), "*")
+	}
+	unit := model.Unit{ID: fmt.Sprintf("u%d", len(l.units)+1)}
+	if num, den, ok := strings.Cut(value, "/"); ok {
+		unit.Divide.Numerator = measures(num)
+		unit.Divide.Denominator = measures(den)
+	} else {
+		unit.Measures = measures(value)
+	}
+	resolveUnitMeasures(&unit, l.instance.Namespaces)
+
+	l.units[value] = unit.ID
+	l.instance.Units = append(l.instance.Units, unit)
+	return unit.ID
+}
+
+// OIM の period（日時又は日時の期間）を、XBRL 2.1 の期間にする
+// 0 時ちょうどの終了日・時点は前日の終わりを表すので、前日の日付にする
+func xbrlPeriod(period string) model.Period {
+	if period == "" {
+		return model.Period{}
+	}
+	if start, end, ok := strings.Cut(period, "/"); ok {
+		return model.Period{StartDate: xbrlDate(start, false), EndDate: xbrlDate(end, true)}
+	}
+	return model.Period{Instant: xbrlDate(period, true)}
+}
+
+func xbrlDate(value string, endOfDay bool) string {
+	t, err := time.Parse("2006-01-02T15:04:05", value)
+	if err != nil || t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+		return value
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t.Format("2006-01-02")
+}
+
+// ファクトの links を脚注リンクにする（リンクグループごとに1つの脚注リンク）
+func (l *xbrlJSONLoader) addFootnoteLinks(doc *xbrlJSONDocument, ids []string, notes map[string]model.Footnote) {
+	linkIndex := make(map[string]int)
+	added := make(map[string]map[string]bool)
+
+	for _, id := range ids {
+		fact := doc.Facts[id]
+		linkTypes := make([]string, 0, len(fact.Links))
+		for linkType := range fact.Links {
+			linkTypes = append(linkTypes, linkType)
+		}
+		sort.Strings(linkTypes)
+
+		for _, linkType := range linkTypes {
+			arcRole, ok := doc.DocumentInfo.LinkTypes[linkType]
+			if !ok {
+				arcRole = xbrlJSONLinkTypes[linkType]
+			}
+			groups := make([]string, 0, len(fact.Links[linkType]))
+			for group := range fact.Links[linkType] {
+				groups = append(groups, group)
+			}
+			sort.Strings(groups)
+
+			for _, group := range groups {
+				role, ok := doc.DocumentInfo.LinkGroups[group]
+				if !ok && group == defaultLinkGroup {
+					role = defaultLinkRole
+				}
+				i, ok := linkIndex[role]
+				if !ok {
+					i = len(l.instance.FootnoteLink)
+					linkIndex[role] = i
+					l.instance.FootnoteLink = append(l.instance.FootnoteLink, model.FootnoteLink{Role: role})
+					added[role] = make(map[string]bool)
+				}
+				link := &l.instance.FootnoteLink[i]
+
+				addRef := func(ref string) {
+					if added[role][ref] {
+						return
+					}
+					added[role][ref] = true
+					if note, ok := notes[ref]; ok {
+						link.Footnotes = append(link.Footnotes, note)
+					} else {
+						link.Locs = append(link.Locs, model.Loc{Label: ref, Href: "#" + ref})
+					}
+				}
+
+				addRef(id)
+				for _, target := range fact.Links[linkType][group] {
+					addRef(target)
+					link.Arcs = append(link.Arcs, model.FootnoteArc{
+						ArcBase: model.ArcBase{From: id, To: target},
+						ArcRole: arcRole,
+					})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/session"
+	"time"
+)
+
+type CacheCommand struct{}
+
+func New() *CacheCommand {
+	return &CacheCommand{}
+}
+
+// キャッシュの操作に提出書類は要らない
+func (c *CacheCommand) EntryLess() bool {
+	return true
+}
+
+const usage = "usage: cache list [-e url-pattern] [-o format] | cache prune [-older 720h] | cache warm <url>..."
+
+type cacheArgs struct {
+	action  string
+	pattern string
+	format  string
+	older   time.Duration
+	urls    []string
+}
+
+func parseArgs(argv []string) (cacheArgs, error) {
+	var a cacheArgs
+	a.action = "list"
+	if len(argv) > 0 && !strings.HasPrefix(argv[0], "-") {
+		a.action, argv = argv[0], argv[1:]
+	}
+
+	fs := flag.NewFlagSet("cache "+a.action, flag.ContinueOnError)
+	switch a.action {
+	case "list":
+		fs.StringVar(&a.pattern, "e", "", "Pattern to match URLs (* = any string)")
+		fs.StringVar(&a.format, "o", "", output.FormatUsage)
+	case "prune":
+		fs.DurationVar(&a.older, "older", 0, "Remove only entries fetched longer ago than this (default: all)")
+	case "warm":
+	default:
+		return a, fmt.Errorf("unknown cache action: %s\n%s", a.action, usage)
+	}
+
+	if err := fs.Parse(argv); err != nil {
+		return a, err
+	}
+
+	if a.action == "warm" {
+		a.urls = fs.Args()
+		if len(a.urls) == 0 {
+			return a, fmt.Errorf("%s", usage)
+		}
+	} else if fs.NArg() > 0 {
+		return a, fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(a.format); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// リモートのファイルのキャッシュを一覧・削除・事前取得する
+func (c *CacheCommand) Execute(s *session.Session, args []string) error {
+
+	a, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	switch a.action {
+	case "list":
+		entries, err := parser.CacheEntries()
+		if err != nil {
+			return err
+		}
+		filtered := []parser.CacheEntry{}
+		for _, entry := range entries {
+			if a.pattern == "" || parser.WildcardMatch(a.pattern, entry.URL) {
+				filtered = append(filtered, entry)
+			}
+		}
+		if len(filtered) == 0 && s.OutputFormat(a.format, output.YAML) == output.YAML {
+			fmt.Fprintf(s.Stdout, "no cache entries in %s.\n", parser.GetCacheConfig().Dir)
+			return nil
+		}
+		return s.Write(a.format, filtered)
+
+	case "prune":
+		n, err := parser.PruneCache(a.older)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "pruned %d entries\n", n)
+
+	case "warm":
+		if parser.GetCacheConfig().Offline {
+			return fmt.Errorf("cache warm is not available in offline mode")
+		}
+		// 取得できない URL があっても残りは続け、失敗をまとめて返す
+		var errs []error
+		for _, url := range a.urls {
+			if err := parser.WarmCache(url); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Fprintln(s.Stdout, "cached", url)
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
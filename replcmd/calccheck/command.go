@@ -0,0 +1,102 @@
+package calccheck
+
+import (
+	"flag"
+	"fmt"
+	"thermal/output"
+	"thermal/session"
+	"thermal/validator"
+)
+
+type CalcCheckCommand struct{}
+
+func New() *CalcCheckCommand {
+	return &CalcCheckCommand{}
+}
+
+func parseArgs(argv []string) (string, string, string, error) {
+	fs := flag.NewFlagSet("calccheck", flag.ContinueOnError)
+	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
+	md := fs.String("m", validator.CalcModeCalc11, "Consistency rules: 2.1 (XBRL 2.1) or 1.1 (Calculations 1.1)")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", "", err
+	}
+
+	return *rt, *md, *of, nil
+}
+
+type OutputInconsistency struct {
+	RoleType     string              `yaml:"RoleType"`
+	Element      string              `yaml:"Element"`
+	ContextRef   string              `yaml:"Context"`
+	UnitRef      string              `yaml:"Unit"`
+	Decimals     string              `yaml:"Decimals"`
+	Total        string              `yaml:"Total"`
+	ComputedSum  string              `yaml:"ComputedSum"`
+	Difference   string              `yaml:"Difference"`
+	Contributors []OutputContributor `yaml:"Contributors"`
+}
+
+type OutputContributor struct {
+	Element  string  `yaml:"Element"`
+	Weight   float64 `yaml:"Weight"`
+	Decimals string  `yaml:"Decimals"`
+	Value    string  `yaml:"Value"`
+}
+
+func (c *CalcCheckCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("calccheck requires an instance document")
+	}
+
+	rtPattern, mode, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	inconsistencies, err := validator.CheckCalculations(s.Schema, s.Instance, mode, rtPattern)
+	if err != nil {
+		return err
+	}
+
+	// 該当なしのメッセージは YAML のときだけ（他の形式では空の一覧を出す）
+	if len(inconsistencies) == 0 && s.OutputFormat(format, output.YAML) == output.YAML {
+		fmt.Fprintln(s.Stdout, "no calculation inconsistencies.")
+		return nil
+	}
+
+	var outputInconsistencies []OutputInconsistency
+	for _, inc := range inconsistencies {
+		out := OutputInconsistency{
+			RoleType:    inc.Role,
+			Element:     fmt.Sprintf("{%s}%s", inc.Total.XMLName.Space, inc.Total.XMLName.Local),
+			ContextRef:  inc.Total.ContextRef,
+			UnitRef:     inc.Total.UnitRef,
+			Decimals:    inc.Decimals,
+			Total:       inc.TotalValue,
+			ComputedSum: inc.ComputedSum,
+			Difference:  inc.Difference,
+		}
+		for _, contributor := range inc.Contributors {
+			out.Contributors = append(out.Contributors, OutputContributor{
+				Element:  fmt.Sprintf("{%s}%s", contributor.Fact.XMLName.Space, contributor.Fact.XMLName.Local),
+				Weight:   contributor.Weight,
+				Decimals: contributor.Fact.Decimals,
+				Value:    contributor.Fact.Value,
+			})
+		}
+		outputInconsistencies = append(outputInconsistencies, out)
+	}
+
+	return s.Write(format, outputInconsistencies)
+}
@@ -0,0 +1,153 @@
+package calculations
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+
+	"github.com/ddddddO/gtree"
+)
+
+type CalculationsCommand struct{}
+
+func New() *CalculationsCommand {
+	return &CalculationsCommand{}
+}
+
+func parseArgs(argv []string) (string, string, bool, string, error) {
+	fs := flag.NewFlagSet("calculations", flag.ContinueOnError)
+	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
+	ls := fs.Bool("l", false, "List role type URIs only")
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en) instead of element names")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", false, "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", false, "", err
+	}
+
+	return *rt, *lang, *ls, *of, nil
+}
+
+type OutputCalculationLink struct {
+	RoleType    string   `yaml:"RoleType"`
+	ElementTree []string `yaml:"ElementTree"`
+}
+
+func (c *CalculationsCommand) Execute(s *session.Session, args []string) error {
+
+	rtPattern, lang, ls, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// -lang 指定時は要素名の代わりにラベルを表示する
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
+	}
+
+	grouped, err := resolver.TraverseCalculationLink(s.Schema)
+	if err != nil {
+		return err
+	}
+
+	arcRoles := make([]string, 0, len(grouped))
+	for k := range grouped {
+		// ロールタイプのフィルタ指定があるときはマッチしたものだけ
+		if rtPattern == "" || parser.WildcardMatch(rtPattern, k) {
+			arcRoles = append(arcRoles, k)
+		}
+	}
+
+	// ロールタイプのフィルタ指定があり、マッチしたのがなかったらエラー
+	if rtPattern != "" && len(arcRoles) == 0 {
+		return fmt.Errorf("roleType not found: %s", rtPattern)
+	}
+
+	sort.Strings(arcRoles)
+
+	if ls {
+		for _, arcRole := range arcRoles {
+			fmt.Fprintln(s.Stdout, arcRole)
+		}
+	} else {
+		var outputElements []OutputCalculationLink
+
+		for _, arcRole := range arcRoles {
+			roots := resolver.FindRootNodes(grouped[arcRole])
+			adj := resolver.BuildAdjacency(grouped[arcRole])
+			visited := map[*model.XMLElement]bool{}
+
+			var trees []string
+			for _, root := range roots {
+				e := root.(*model.XMLElement)
+				groot := gtree.NewRoot(fmt.Sprintf("Root,%s", labels.DisplayName(e, "", lang)))
+				dfs(e, visited, adj, groot, labels, lang)
+
+				var buf bytes.Buffer
+				if err := gtree.OutputFromRoot(&buf, groot); err != nil {
+					return err
+				}
+				trees = append(trees, buf.String())
+			}
+			outputElements = append(outputElements, OutputCalculationLink{
+				RoleType:    arcRole,
+				ElementTree: trees,
+			})
+		}
+		return s.Write(format, outputElements)
+	}
+	return nil
+}
+
+// 重みを符号付きで表記する（例: +1, -1）
+func formatWeight(weight string) string {
+	w := strings.TrimSpace(weight)
+	if !strings.HasPrefix(w, "-") && !strings.HasPrefix(w, "+") {
+		w = "+" + w
+	}
+	return w
+}
+
+func dfs(node *model.XMLElement, visited map[*model.XMLElement]bool, adj map[any][]*resolver.ArcRelation, gnode *gtree.Node, labels *resolver.LabelResolver, lang string) {
+	if visited[node] {
+		return
+	}
+	visited[node] = true
+
+	relations := adj[node]
+	sort.Slice(relations, func(i, j int) bool {
+		return resolver.ArcOrder(relations[i]) < resolver.ArcOrder(relations[j])
+	})
+
+	for _, child := range relations {
+		to := child.To.(*model.XMLElement)
+		arc := child.Arc.(*model.CalculationArc)
+		text := fmt.Sprintf("%s,%s,%s", arc.Order, labels.DisplayName(to, "", lang), formatWeight(arc.Weight))
+		gnodec := gnode.Add(text)
+
+		visitedCopy := make(map[*model.XMLElement]bool)
+		maps.Copy(visitedCopy, visited)
+
+		dfs(to, visitedCopy, adj, gnodec, labels, lang)
+	}
+}
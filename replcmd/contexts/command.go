@@ -3,12 +3,10 @@ package contexts
 import (
 	"flag"
 	"fmt"
-	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/session"
-
-	"gopkg.in/yaml.v3"
 )
 
 type ContextsCommand struct{}
@@ -17,40 +15,49 @@ func New() *ContextsCommand {
 	return &ContextsCommand{}
 }
 
-func parseArgs(args string) (string, bool, error) {
+func parseArgs(argv []string) (string, bool, string, error) {
 	fs := flag.NewFlagSet("facts", flag.ContinueOnError)
 	cx := fs.String("c", "", "Pattern to match context IDs (* = any string)")
 	ls := fs.Bool("l", false, "List role type URIs only")
-
-	argv := strings.Fields(args)
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", false, err
+		return "", false, "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", false, "", err
 	}
 
-	return *cx, *ls, nil
+	return *cx, *ls, *of, nil
 }
 
 type OutputContext struct {
-	ID       string         `yaml:"ID"`
-	Entity   model.Entity   `yaml:"Entity"`
-	Period   model.Period   `yaml:"Period"`
-	Scenario model.Scenario `yaml:"Scenario"`
+	ID         string            `yaml:"ID"`
+	Entity     model.Identifier  `yaml:"Entity"`
+	Period     model.Period      `yaml:"Period"`
+	Dimensions []OutputDimension `yaml:"Dimensions"`
+}
+
+type OutputDimension struct {
+	Dimension      string `yaml:"Dimension"`
+	Member         string `yaml:"Member"`
+	Typed          bool   `yaml:"Typed"`
+	ContextElement string `yaml:"ContextElement"`
 }
 
-func (c *ContextsCommand) Execute(s *session.Session, args string) {
+func (c *ContextsCommand) Execute(s *session.Session, args []string) error {
 	if s.Instance == nil {
-		return
+		return fmt.Errorf("contexts requires an instance document")
 	}
 
-	cxPattern, ls, err := parseArgs(args)
+	cxPattern, ls, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	var outputContexts []OutputContext
@@ -64,19 +71,24 @@ func (c *ContextsCommand) Execute(s *session.Session, args string) {
 			fmt.Fprintln(s.Stdout, context.ID)
 		} else {
 			outCxt := OutputContext{
-				ID:       context.ID,
-				Entity:   context.Entity,
-				Period:   context.Period,
-				Scenario: context.Scenario,
+				ID:     context.ID,
+				Entity: context.Entity.Identifier,
+				Period: context.Period,
 			}
-			outputContexts = append(outputContexts, outCxt)
-
-			encoder := yaml.NewEncoder(s.Stdout)
-			encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-			if err := encoder.Encode(outputContexts); err != nil {
-				fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
+			for _, dim := range context.Dimensions() {
+				outCxt.Dimensions = append(outCxt.Dimensions, OutputDimension{
+					Dimension:      dim.Dimension,
+					Member:         dim.Member,
+					Typed:          dim.Typed,
+					ContextElement: dim.ContextElement,
+				})
 			}
+			outputContexts = append(outputContexts, outCxt)
 		}
 	}
+
+	if !ls {
+		return s.Write(format, outputContexts)
+	}
+	return nil
 }
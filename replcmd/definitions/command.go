@@ -5,17 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"maps"
-	"os"
 	"sort"
-	"strconv"
 	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/resolver"
 	"thermal/session"
 
 	"github.com/ddddddO/gtree"
-	"gopkg.in/yaml.v3"
 )
 
 type DefinitionsCommand struct{}
@@ -24,22 +22,41 @@ func New() *DefinitionsCommand {
 	return &DefinitionsCommand{}
 }
 
-func parseArgs(args string) (string, bool, error) {
+type definitionsArgs struct {
+	rtPattern string
+	arPattern string
+	lang      string
+	ls        bool
+	format    string
+}
+
+func parseArgs(argv []string) (definitionsArgs, error) {
 	fs := flag.NewFlagSet("definitions", flag.ContinueOnError)
 	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
+	ar := fs.String("a", "", "Pattern to match arcroles, full URI or last segment (e.g. all, domain-member, general-*)")
 	ls := fs.Bool("l", false, "List role type URIs only")
-
-	argv := strings.Fields(args)
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en) instead of element names")
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", false, err
+		return definitionsArgs{}, err
 	}
 
 	if fs.NArg() > 0 {
-		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return definitionsArgs{}, fmt.Errorf("unknown parameter: %v", fs.Args())
 	}
 
-	return *rt, *ls, nil
+	if err := output.ValidateFormat(*of); err != nil {
+		return definitionsArgs{}, err
+	}
+
+	return definitionsArgs{
+		rtPattern: *rt,
+		arPattern: *ar,
+		lang:      *lang,
+		ls:        *ls,
+		format:    *of,
+	}, nil
 }
 
 type OutputDefinitionLink struct {
@@ -47,18 +64,50 @@ type OutputDefinitionLink struct {
 	ElementTree []string `yaml:"ElementTree"`
 }
 
-func (c *DefinitionsCommand) Execute(s *session.Session, args string) {
+func (c *DefinitionsCommand) Execute(s *session.Session, args []string) error {
 
-	rtPattern, ls, err := parseArgs(args)
+	a, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
+	}
+	rtPattern, lang, ls := a.rtPattern, a.lang, a.ls
+
+	// -lang 指定時は要素名の代わりにラベルを表示する
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
 	}
 
 	grouped, err := resolver.TraverseDefinitionLink(s.Schema)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
+	}
+
+	// アークロールのフィルタ指定があるときはマッチしたアークだけ
+	if a.arPattern != "" {
+		for role, relations := range grouped {
+			var filtered []resolver.ArcRelation
+			for _, relation := range relations {
+				arcRole := relation.Arc.(*model.DefinitionArc).ArcRole
+				if parser.WildcardMatch(a.arPattern, arcRole) || parser.WildcardMatch(a.arPattern, resolver.ShortArcRole(arcRole)) {
+					filtered = append(filtered, relation)
+				}
+			}
+			if len(filtered) == 0 {
+				delete(grouped, role)
+			} else {
+				grouped[role] = filtered
+			}
+		}
+	}
+
+	// targetRole の先のELRでも辿れるように、全ELRの隣接リストを作っておく
+	adjByRole := make(map[string]map[any][]*resolver.ArcRelation, len(grouped))
+	for role, relations := range grouped {
+		adjByRole[role] = resolver.BuildAdjacency(relations)
 	}
 
 	arcRoles := make([]string, 0, len(grouped))
@@ -71,8 +120,7 @@ func (c *DefinitionsCommand) Execute(s *session.Session, args string) {
 
 	// ロールタイプのフィルタ指定があり、マッチしたのがなかったらエラー
 	if rtPattern != "" && len(arcRoles) == 0 {
-		fmt.Fprintf(s.Stdout, "roleType not found. %s \n", rtPattern)
-		return
+		return fmt.Errorf("roleType not found: %s", rtPattern)
 	}
 
 	sort.Strings(arcRoles)
@@ -86,19 +134,25 @@ func (c *DefinitionsCommand) Execute(s *session.Session, args string) {
 
 		for _, arcRole := range arcRoles {
 			roots := resolver.FindRootNodes(grouped[arcRole])
-			adj := resolver.BuildAdjacency(grouped[arcRole])
-			visited := map[*model.XMLElement]bool{}
+			sort.Slice(roots, func(i, j int) bool {
+				return roots[i].(*model.XMLElement).Name < roots[j].(*model.XMLElement).Name
+			})
+			t := &treeWalker{
+				baseRole:  arcRole,
+				adjByRole: adjByRole,
+				labels:    labels,
+				lang:      lang,
+			}
 
 			var trees []string
 			for _, root := range roots {
 				e := root.(*model.XMLElement)
-				groot := gtree.NewRoot(fmt.Sprintf("Root,%s", e.Name))
-				dfs(e, visited, adj, groot)
+				groot := gtree.NewRoot(fmt.Sprintf("Root,%s", labels.DisplayName(e, "", lang)))
+				t.dfs(e, arcRole, "", map[*model.XMLElement]bool{}, groot)
 
 				var buf bytes.Buffer
 				if err := gtree.OutputFromRoot(&buf, groot); err != nil {
-					fmt.Fprintln(s.Stderr, "error:", err)
-					return
+					return err
 				}
 				trees = append(trees, buf.String())
 			}
@@ -107,47 +161,67 @@ func (c *DefinitionsCommand) Execute(s *session.Session, args string) {
 				ElementTree: trees,
 			})
 		}
-		encoder := yaml.NewEncoder(os.Stdout)
-		encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-		if err := encoder.Encode(outputElements); err != nil {
-			fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-		}
+		return s.Write(a.format, outputElements)
 	}
+	return nil
+}
+
+// 定義リンクのツリーを作る
+type treeWalker struct {
+	baseRole  string
+	adjByRole map[string]map[any][]*resolver.ArcRelation
+	labels    *resolver.LabelResolver
+	lang      string
 }
 
-func dfs(node *model.XMLElement, visited map[*model.XMLElement]bool, adj map[any][]*resolver.ArcRelation, gnode *gtree.Node) {
+// role は node の子をたどるELR、arcRole は node に至ったアークのアークロール
+// targetRole 付きのアークの先は、そのELRで続きをたどる
+func (t *treeWalker) dfs(node *model.XMLElement, role, arcRole string, visited map[*model.XMLElement]bool, gnode *gtree.Node) {
 	if visited[node] {
 		return
 	}
 	visited[node] = true
 
-	relations := adj[node]
+	relations := t.adjByRole[role][node]
 	sort.Slice(relations, func(i, j int) bool {
-		arci := relations[i].Arc.(*model.DefinitionArc)
-		fi, err := strconv.ParseFloat(arci.Order, 64)
-		if err != nil {
-			fi = 1.0
-		}
-
-		arcj := relations[j].Arc.(*model.DefinitionArc)
-		fj, err := strconv.ParseFloat(arcj.Order, 64)
-		if err != nil {
-			fj = 1.0
-		}
-
-		return fi < fj
+		return resolver.ArcOrder(relations[i]) < resolver.ArcOrder(relations[j])
 	})
 
 	for _, child := range relations {
 		to := child.To.(*model.XMLElement)
 		arc := child.Arc.(*model.DefinitionArc)
-		text := fmt.Sprintf("%s,%s", arc.Order, to.Name)
-		gnodec := gnode.Add(text)
+		if !resolver.IsConsecutiveArcRole(arcRole, arc.ArcRole) {
+			continue
+		}
+
+		nextRole := role
+		if arc.TargetRole != "" {
+			nextRole = arc.TargetRole
+		}
+
+		// order,要素名,アークロール[,XDT属性]
+		fields := []string{arc.Order, t.labels.DisplayName(to, "", t.lang), resolver.ShortArcRole(arc.ArcRole)}
+		if arc.Closed != "" {
+			fields = append(fields, "closed="+arc.Closed)
+		}
+		if arc.ContextElement != "" {
+			fields = append(fields, "contextElement="+arc.ContextElement)
+		}
+		if arc.Usable != "" {
+			fields = append(fields, "usable="+arc.Usable)
+		}
+		if arc.TargetRole != "" {
+			fields = append(fields, "targetRole="+arc.TargetRole)
+		}
+		// targetRole をたどって別のELRに移った関係には、そのELRを付記する
+		if role != t.baseRole {
+			fields = append(fields, "@"+role)
+		}
+		gnodec := gnode.Add(strings.Join(fields, ","))
 
 		visitedCopy := make(map[*model.XMLElement]bool)
 		maps.Copy(visitedCopy, visited)
 
-		dfs(to, visitedCopy, adj, gnodec)
+		t.dfs(to, nextRole, arc.ArcRole, visitedCopy, gnodec)
 	}
 }
@@ -0,0 +1,146 @@
+package diagnostics
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+)
+
+type DiagnosticsCommand struct{}
+
+func New() *DiagnosticsCommand {
+	return &DiagnosticsCommand{}
+}
+
+// 重要度の順位（-s で指定した重要度以上を表示する）
+var severityRank = map[model.Severity]int{
+	model.SeverityInfo:    0,
+	model.SeverityWarning: 1,
+	model.SeverityError:   2,
+}
+
+type diagnosticsArgs struct {
+	severity string
+	code     string
+	file     string
+	element  string
+	mode     string
+	clear    bool
+	format   string
+}
+
+func parseArgs(argv []string) (diagnosticsArgs, error) {
+	var a diagnosticsArgs
+	fs := flag.NewFlagSet("diagnostics", flag.ContinueOnError)
+	fs.StringVar(&a.severity, "s", "", "Minimum severity to list (error|warning|info)")
+	fs.StringVar(&a.code, "c", "", "Pattern to match codes (* = any string)")
+	fs.StringVar(&a.file, "f", "", "Pattern to match file paths (* = any string)")
+	fs.StringVar(&a.element, "e", "", "Pattern to match elements (* = any string)")
+	fs.StringVar(&a.mode, "mode", "", "Set how broken arcs are handled: strict (abort) or lenient (skip)")
+	fs.BoolVar(&a.clear, "clear", false, "Clear the recorded diagnostics")
+	fs.StringVar(&a.format, "o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return a, err
+	}
+
+	if fs.NArg() > 0 {
+		return a, fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if _, ok := severityRank[model.Severity(a.severity)]; a.severity != "" && !ok {
+		return a, fmt.Errorf("unknown severity: %s (error|warning|info)", a.severity)
+	}
+	if a.mode != "" && a.mode != "strict" && a.mode != "lenient" {
+		return a, fmt.Errorf("unknown mode: %s (strict|lenient)", a.mode)
+	}
+
+	if err := output.ValidateFormat(a.format); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// DTSの読込み・関係の解決で記録した問題を一覧する
+func (c *DiagnosticsCommand) Execute(s *session.Session, args []string) error {
+
+	a, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if a.mode != "" {
+		parser.SetStrict(a.mode == "strict")
+		fmt.Fprintln(s.Stdout, "mode:", a.mode)
+		return nil
+	}
+	if a.clear {
+		n := len(parser.Diagnostics())
+		parser.ClearDiagnostics()
+		fmt.Fprintf(s.Stdout, "cleared %d diagnostics\n", n)
+		return nil
+	}
+
+	// 全てのリンクを一度解決して、関係の問題も記録しておく
+	// 厳格モードで解決が中断されたときも、記録済みの診断情報を表示してからエラーを返す
+	errs := resolveAll(s)
+
+	filtered := []model.Diagnostic{}
+	for _, d := range parser.Diagnostics() {
+		if a.severity != "" && severityRank[d.Severity] < severityRank[model.Severity(a.severity)] {
+			continue
+		}
+		if a.code != "" && !parser.WildcardMatch(a.code, d.Code) {
+			continue
+		}
+		if a.file != "" && !parser.WildcardMatch(a.file, d.File) {
+			continue
+		}
+		if a.element != "" && !parser.WildcardMatch(a.element, d.Element) {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+
+	if len(filtered) == 0 && s.OutputFormat(a.format, output.YAML) == output.YAML {
+		fmt.Fprintln(s.Stdout, "no diagnostics.")
+	} else if err := s.Write(a.format, filtered); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// 厳格モードでは、最初に見つかった壊れたアーク・ロケータがエラーとして返る
+func resolveAll(s *session.Session) []error {
+	var errs []error
+	if s.Schema != nil {
+		traversals := []func(*model.XBRLSchema) (map[string][]resolver.ArcRelation, error){
+			resolver.TraverseLabelLink,
+			resolver.TraverseReferenceLink,
+			resolver.TraversePresentationLink,
+			resolver.TraverseDefinitionLink,
+			resolver.TraverseCalculationLink,
+		}
+		for _, traverse := range traversals {
+			if _, err := traverse(s.Schema); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		roleTypes := make(map[string]*model.RoleType)
+		resolver.CollectRoleTypesByHref(s.Schema, roleTypes)
+		if _, err := resolver.TraverseGenericLink(s.Schema, roleTypes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.Instance != nil {
+		if _, err := resolver.TraverseFootnoteLink(s.Instance); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
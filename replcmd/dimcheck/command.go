@@ -0,0 +1,86 @@
+package dimcheck
+
+import (
+	"flag"
+	"fmt"
+	"thermal/output"
+	"thermal/session"
+	"thermal/validator"
+)
+
+type DimCheckCommand struct{}
+
+func New() *DimCheckCommand {
+	return &DimCheckCommand{}
+}
+
+func parseArgs(argv []string) (string, error) {
+	fs := flag.NewFlagSet("dimcheck", flag.ContinueOnError)
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", err
+	}
+
+	return *of, nil
+}
+
+type OutputDimensionIssue struct {
+	Code       string `yaml:"Code"`
+	Message    string `yaml:"Message"`
+	Element    string `yaml:"Element,omitempty"`
+	FactID     string `yaml:"FactID,omitempty"`
+	Value      string `yaml:"Value,omitempty"`
+	ContextRef string `yaml:"Context"`
+	RoleType   string `yaml:"RoleType,omitempty"`
+	Hypercube  string `yaml:"Hypercube,omitempty"`
+}
+
+func (c *DimCheckCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("dimcheck requires an instance document")
+	}
+
+	format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	issues, err := validator.CheckDimensions(s.Schema, s.Instance)
+	if err != nil {
+		return err
+	}
+
+	// 該当なしのメッセージは YAML のときだけ（他の形式では空の一覧を出す）
+	if len(issues) == 0 && s.OutputFormat(format, output.YAML) == output.YAML {
+		fmt.Fprintln(s.Stdout, "no dimensional errors.")
+		return nil
+	}
+
+	var outputIssues []OutputDimensionIssue
+	for _, issue := range issues {
+		out := OutputDimensionIssue{
+			Code:       issue.Code,
+			Message:    issue.Message,
+			ContextRef: issue.Context,
+			RoleType:   issue.Role,
+			Hypercube:  issue.Hypercube,
+		}
+		if issue.Fact != nil {
+			out.Element = fmt.Sprintf("{%s}%s", issue.Fact.XMLName.Space, issue.Fact.XMLName.Local)
+			out.FactID = issue.Fact.ID
+			out.Value = issue.Fact.Value
+		}
+		outputIssues = append(outputIssues, out)
+	}
+
+	return s.Write(format, outputIssues)
+}
@@ -0,0 +1,253 @@
+package dimensions
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+
+	"github.com/ddddddO/gtree"
+)
+
+type DimensionsCommand struct{}
+
+func New() *DimensionsCommand {
+	return &DimensionsCommand{}
+}
+
+func parseArgs(argv []string) (string, string, string, string, error) {
+	fs := flag.NewFlagSet("dimensions", flag.ContinueOnError)
+	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
+	el := fs.String("e", "", "Pattern to match primary item names; shows dimensions and members the item may be reported with (* = any string)")
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en) instead of element names")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", "", "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", "", "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", "", "", err
+	}
+
+	return *rt, *el, *lang, *of, nil
+}
+
+type OutputDimensionalELR struct {
+	RoleType     string            `yaml:"RoleType"`
+	PrimaryItems []string          `yaml:"PrimaryItems"`
+	Hypercubes   []OutputHypercube `yaml:"Hypercubes"`
+}
+
+type OutputPrimaryItem struct {
+	Element    string            `yaml:"Element"`
+	Hypercubes []OutputHypercube `yaml:"Hypercubes"`
+}
+
+type OutputHypercube struct {
+	RoleType       string            `yaml:"RoleType,omitempty"`
+	Hypercube      string            `yaml:"Hypercube"`
+	PrimaryItem    string            `yaml:"PrimaryItem"`
+	ArcRole        string            `yaml:"ArcRole"`
+	Closed         bool              `yaml:"Closed"`
+	ContextElement string            `yaml:"ContextElement"`
+	Dimensions     []OutputDimension `yaml:"Dimensions"`
+}
+
+type OutputDimension struct {
+	Dimension   string   `yaml:"Dimension"`
+	RoleType    string   `yaml:"RoleType"`
+	TypedDomain string   `yaml:"TypedDomain,omitempty"`
+	Default     string   `yaml:"Default,omitempty"`
+	DomainTree  []string `yaml:"DomainTree,omitempty"`
+	Members     []string `yaml:"Members,omitempty"`
+}
+
+func (c *DimensionsCommand) Execute(s *session.Session, args []string) error {
+
+	rtPattern, elPattern, lang, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
+	}
+
+	dm, err := resolver.BuildDimensionalModel(s.Schema)
+	if err != nil {
+		return err
+	}
+
+	f := &formatter{labels: labels, lang: lang}
+
+	var out any
+	if elPattern != "" {
+		out, err = f.primaryItems(dm, rtPattern, elPattern)
+	} else {
+		out, err = f.elrs(dm, rtPattern)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.Write(format, out)
+}
+
+type formatter struct {
+	labels *resolver.LabelResolver
+	lang   string
+}
+
+func (f *formatter) name(elem *model.XMLElement) string {
+	if elem == nil {
+		return ""
+	}
+	return f.labels.DisplayName(elem, "", f.lang)
+}
+
+// ELRごとに基本項目とハイパーキューブを一覧にする
+func (f *formatter) elrs(dm *resolver.DimensionalModel, rtPattern string) ([]OutputDimensionalELR, error) {
+	var result []OutputDimensionalELR
+	for _, elr := range dm.ELRs {
+		if rtPattern != "" && !parser.WildcardMatch(rtPattern, elr.Role) {
+			continue
+		}
+		out := OutputDimensionalELR{RoleType: elr.Role}
+		for _, item := range elr.PrimaryItems {
+			out.PrimaryItems = append(out.PrimaryItems, strings.Repeat("  ", item.Depth)+f.name(item.Element))
+		}
+		for _, hc := range elr.Hypercubes {
+			h, err := f.hypercube(hc, false, true)
+			if err != nil {
+				return nil, err
+			}
+			out.Hypercubes = append(out.Hypercubes, h)
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+// 基本項目ごとに、報告に使えるディメンションとメンバーを一覧にする
+func (f *formatter) primaryItems(dm *resolver.DimensionalModel, rtPattern, elPattern string) ([]OutputPrimaryItem, error) {
+	byElement := make(map[*model.XMLElement]*OutputPrimaryItem)
+	var order []*model.XMLElement
+	for _, elr := range dm.ELRs {
+		if rtPattern != "" && !parser.WildcardMatch(rtPattern, elr.Role) {
+			continue
+		}
+		for _, item := range elr.PrimaryItems {
+			if !parser.WildcardMatch(elPattern, item.Element.Name) {
+				continue
+			}
+			out, ok := byElement[item.Element]
+			if !ok {
+				out = &OutputPrimaryItem{Element: fmt.Sprintf("{%s}%s", item.Element.Schema.TargetNS, item.Element.Name)}
+				byElement[item.Element] = out
+				order = append(order, item.Element)
+			}
+			for _, hc := range item.Hypercubes {
+				h, err := f.hypercube(hc, true, false)
+				if err != nil {
+					return nil, err
+				}
+				out.Hypercubes = append(out.Hypercubes, h)
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].Name < order[j].Name
+	})
+	result := make([]OutputPrimaryItem, 0, len(order))
+	for _, elem := range order {
+		result = append(result, *byElement[elem])
+	}
+	return result, nil
+}
+
+// withRole はELRを出力するか、tree はドメインをツリーで出力するか（false なら使用可能なメンバーの一覧）
+func (f *formatter) hypercube(hc *resolver.Hypercube, withRole, tree bool) (OutputHypercube, error) {
+	out := OutputHypercube{
+		Hypercube:      f.name(hc.Element),
+		PrimaryItem:    f.name(hc.PrimaryItem),
+		ArcRole:        resolver.ShortArcRole(hc.ArcRole),
+		Closed:         hc.Closed,
+		ContextElement: hc.ContextElement,
+	}
+	if withRole {
+		out.RoleType = hc.Role
+	}
+	for _, dim := range hc.Dimensions {
+		d := OutputDimension{
+			Dimension: f.name(dim.Element),
+			RoleType:  dim.Role,
+			Default:   f.name(dim.Default),
+		}
+		if dim.Element.TypedDomainRef != "" {
+			d.TypedDomain = dim.Element.TypedDomainRef
+			if dim.TypedDomain != nil {
+				d.TypedDomain = fmt.Sprintf("%s (%s)", dim.TypedDomain.Name, dim.TypedDomain.Type)
+			}
+		} else if tree {
+			trees, err := f.domainTrees(dim)
+			if err != nil {
+				return OutputHypercube{}, err
+			}
+			d.DomainTree = trees
+		} else {
+			for _, member := range dim.Members() {
+				if member.Usable {
+					d.Members = append(d.Members, f.name(member.Element))
+				}
+			}
+		}
+		out.Dimensions = append(out.Dimensions, d)
+	}
+	return out, nil
+}
+
+// ドメインとメンバーをツリーで表す。使用不可のメンバーには (unusable) を付ける
+func (f *formatter) domainTrees(dim *resolver.Dimension) ([]string, error) {
+	var trees []string
+	for _, domain := range dim.Domains {
+		groot := gtree.NewRoot(f.memberText(domain))
+		f.addMembers(domain, groot)
+
+		var buf bytes.Buffer
+		if err := gtree.OutputFromRoot(&buf, groot); err != nil {
+			return nil, err
+		}
+		trees = append(trees, buf.String())
+	}
+	return trees, nil
+}
+
+func (f *formatter) addMembers(member *resolver.DomainMember, gnode *gtree.Node) {
+	for _, child := range member.Children {
+		f.addMembers(child, gnode.Add(f.memberText(child)))
+	}
+}
+
+func (f *formatter) memberText(member *resolver.DomainMember) string {
+	text := f.name(member.Element)
+	if !member.Usable {
+		text += " (unusable)"
+	}
+	return text
+}
@@ -14,7 +14,7 @@ func New() *DtsCommand {
 	return &DtsCommand{}
 }
 
-func (c *DtsCommand) Execute(s *session.Session, args string) {
+func (c *DtsCommand) Execute(s *session.Session, args []string) error {
 	var root *gtree.Node
 	if s.Manifest != nil {
 		root = manifestTree(s.Manifest)
@@ -23,9 +23,7 @@ func (c *DtsCommand) Execute(s *session.Session, args string) {
 	} else if s.Schema != nil {
 		root = schemaTree(s.Schema)
 	}
-	if err := gtree.OutputFromRoot(s.Stdout, root); err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-	}
+	return gtree.OutputFromRoot(s.Stdout, root)
 }
 
 func manifestTree(manifest *model.Manifest) *gtree.Node {
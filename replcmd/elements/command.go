@@ -6,10 +6,10 @@ import (
 	"sort"
 	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
+	"thermal/resolver"
 	"thermal/session"
-
-	"gopkg.in/yaml.v3"
 )
 
 type ElementsCommand struct{}
@@ -18,26 +18,31 @@ func New() *ElementsCommand {
 	return &ElementsCommand{}
 }
 
-func parseArgs(args string) (string, bool, error) {
+func parseArgs(argv []string) (string, string, bool, string, error) {
 	fs := flag.NewFlagSet("elements", flag.ContinueOnError)
 	el := fs.String("e", "", "Pattern to match element names (* = any string)")
 	ls := fs.Bool("l", false, "List element names only")
-
-	argv := strings.Fields(args)
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en)")
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", false, err
+		return "", "", false, "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", false, "", err
 	}
 
-	return *el, *ls, nil
+	return *el, *lang, *ls, *of, nil
 }
 
 type OutputElement struct {
 	Name       string `yaml:"Name"`
+	Label      string `yaml:"Label,omitempty"`
 	Namespace  string `yaml:"Namespace"`
 	Type       string `yaml:"Type"`
 	PeriodType string `yaml:"PeriodType"`
@@ -46,18 +51,24 @@ type OutputElement struct {
 	Href       string `yaml:"Href"`
 }
 
-func (c *ElementsCommand) Execute(s *session.Session, args string) {
+func (c *ElementsCommand) Execute(s *session.Session, args []string) error {
 
-	elPattern, ls, err := parseArgs(args)
+	elPattern, lang, ls, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
+	}
+
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
 	}
 
 	elements, err := schemaTree(s.Schema)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	sort.Slice(elements, func(i, j int) bool {
@@ -78,6 +89,9 @@ func (c *ElementsCommand) Execute(s *session.Session, args string) {
 			Abstract:   element.Abstract,
 			Nillable:   element.Nillable,
 		}
+		if labels != nil {
+			outputElement.Label = labels.Label(element, "", lang)
+		}
 		outputElement.Href = fmt.Sprintf("%s#%s", element.Schema.Path, element.Id)
 		outputElements = append(outputElements, outputElement)
 	}
@@ -86,13 +100,9 @@ func (c *ElementsCommand) Execute(s *session.Session, args string) {
 			fmt.Fprintln(s.Stdout, outputElement.Name)
 		}
 	} else {
-		encoder := yaml.NewEncoder(s.Stdout)
-		encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-		if err := encoder.Encode(outputElements); err != nil {
-			fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-		}
+		return s.Write(format, outputElements)
 	}
+	return nil
 }
 
 func schemaTree(schema *model.XBRLSchema) ([]*model.XMLElement, error) {
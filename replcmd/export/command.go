@@ -0,0 +1,179 @@
+package export
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"thermal/exporter"
+	"thermal/resolver"
+	"thermal/session"
+)
+
+type ExportCommand struct{}
+
+func New() *ExportCommand {
+	return &ExportCommand{}
+}
+
+// 出力の種類と、csv形式（xbrl-json は JSON）文字列の作成処理
+// instance が true のものはインスタンスを読み込んでいるときだけ出力できる
+type exportKind struct {
+	instance bool
+	export   func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error)
+}
+
+var exportKinds = map[string]exportKind{
+	"dts": {true, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvDts(s.Instance, header)
+	}},
+	"roletypes": {false, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvRoleTypes(s.Schema, header)
+	}},
+	"genericlinks": {false, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvGenericLinks(s.Schema, header)
+	}},
+	"elements": {false, func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error) {
+		return exporter.CsvElements(s.Schema, labels, lang, header)
+	}},
+	"labels": {false, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvLabels(s.Schema, header)
+	}},
+	"references": {false, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvReferences(s.Schema, header)
+	}},
+	"presentations": {false, func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error) {
+		return exporter.CsvPresentationLinks(s.Schema, labels, lang, header)
+	}},
+	"definitions": {false, func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error) {
+		return exporter.CsvDefinitionLinks(s.Schema, labels, lang, header)
+	}},
+	"calculations": {false, func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error) {
+		return exporter.CsvCalculationLinks(s.Schema, labels, lang, header)
+	}},
+	"facts": {true, func(s *session.Session, labels *resolver.LabelResolver, lang string, header bool) (string, error) {
+		return exporter.CsvFacts(s.Instance, labels, lang, header)
+	}},
+	"contexts": {true, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvContexts(s.Instance, header)
+	}},
+	"units": {true, func(s *session.Session, _ *resolver.LabelResolver, _ string, header bool) (string, error) {
+		return exporter.CsvUnits(s.Instance, header)
+	}},
+	"xbrl-json": {true, func(s *session.Session, _ *resolver.LabelResolver, _ string, _ bool) (string, error) {
+		return exporter.XBRLJSON(s.Instance)
+	}},
+}
+
+// 複数のファイルをレポートパッケージ（ZIP）に出力する種類（インスタンスが必要）
+// ZIP内のパス → 内容 を返す
+var packageKinds = map[string]func(s *session.Session, file string) (map[string]string, error){
+	"xbrl-csv": func(s *session.Session, file string) (map[string]string, error) {
+		return exporter.XBRLCSVReportPackage(s.Instance, file)
+	},
+}
+
+var kindNames = []string{
+	"dts", "roletypes", "genericlinks", "elements", "labels", "references",
+	"presentations", "definitions", "calculations", "facts", "contexts", "units",
+	"xbrl-json", "xbrl-csv",
+}
+
+type exportArgs struct {
+	kind   string
+	file   string
+	header bool
+	bom    bool
+	lang   string
+}
+
+func parseArgs(argv []string) (exportArgs, error) {
+	var a exportArgs
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&a.file, "file", "", "Write to the given file instead of standard output (report package .xbr|.zip for xbrl-csv)")
+	fs.BoolVar(&a.header, "header", false, "Write a header row")
+	fs.BoolVar(&a.bom, "bom", false, "Prepend a UTF-8 BOM (for Excel)")
+	fs.StringVar(&a.lang, "lang", "", "Add label columns in the given language (ja/en) where supported")
+
+	// 種類はフラグの前後どちらにも書ける
+	if len(argv) > 0 && !strings.HasPrefix(argv[0], "-") {
+		a.kind, argv = argv[0], argv[1:]
+	}
+	if err := fs.Parse(argv); err != nil {
+		return a, err
+	}
+	if a.kind == "" && fs.NArg() > 0 {
+		a.kind = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return a, err
+		}
+	}
+
+	if fs.NArg() > 0 {
+		return a, fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+	if a.kind == "" {
+		return a, fmt.Errorf("usage: export <%s> [-file path] [-header] [-bom] [-lang ja|en]", strings.Join(kindNames, "|"))
+	}
+	if _, ok := packageKinds[a.kind]; ok {
+		if a.file == "" {
+			return a, fmt.Errorf("%s requires an output report package (-file report.xbr|report.zip)", a.kind)
+		}
+		return a, nil
+	}
+	if _, ok := exportKinds[a.kind]; !ok {
+		return a, fmt.Errorf("unknown export kind: %s (%s)", a.kind, strings.Join(kindNames, ", "))
+	}
+
+	return a, nil
+}
+
+func (c *ExportCommand) Execute(s *session.Session, args []string) error {
+
+	a, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if export, ok := packageKinds[a.kind]; ok {
+		if s.Instance == nil {
+			return fmt.Errorf("%s requires an instance document", a.kind)
+		}
+		files, err := export(s, a.file)
+		if err != nil {
+			return err
+		}
+		if err := exporter.WriteZip(a.file, files, a.bom); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.Stdout, "exported %s to %s (%d files)\n", a.kind, a.file, len(files))
+		return nil
+	}
+
+	kind := exportKinds[a.kind]
+	if kind.instance && s.Instance == nil {
+		return fmt.Errorf("%s requires an instance document", a.kind)
+	}
+
+	var labels *resolver.LabelResolver
+	if a.lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := kind.export(s, labels, a.lang, a.header)
+	if err != nil {
+		return err
+	}
+
+	if a.file == "" {
+		return exporter.Write(s.Stdout, content, a.bom)
+	}
+
+	if err := exporter.WriteFile(a.file, content, a.bom); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.Stdout, "exported %s to %s\n", a.kind, a.file)
+	return nil
+}
@@ -0,0 +1,81 @@
+package extract
+
+import (
+	"flag"
+	"fmt"
+	"thermal/exporter"
+	"thermal/model"
+	"thermal/parser"
+	"thermal/session"
+)
+
+type ExtractCommand struct{}
+
+func New() *ExtractCommand {
+	return &ExtractCommand{}
+}
+
+func parseArgs(argv []string) (string, bool, error) {
+	fs := flag.NewFlagSet("extract", flag.ContinueOnError)
+	file := fs.String("file", "", "Write the instance document to the given file (.xbrl) instead of standard output")
+	noVerify := fs.Bool("no-verify", false, "Skip re-parsing the written file to verify the fact set")
+
+	if err := fs.Parse(argv); err != nil {
+		return "", false, err
+	}
+
+	if fs.NArg() > 0 {
+		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	return *file, !*noVerify, nil
+}
+
+// 読み込んだインスタンス（InlineXBRL から組み立てたものを含む）を XBRL 2.1 のインスタンス文書にする
+// ファイルに書いたときは、読み直して同じファクトになることを確かめる
+func (c *ExtractCommand) Execute(s *session.Session, args []string) error {
+
+	file, verify, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if s.Instance == nil {
+		return fmt.Errorf("extract requires an instance document")
+	}
+
+	content, err := exporter.XBRLInstanceXML(s.Instance, file)
+	if err != nil {
+		return err
+	}
+
+	if file == "" {
+		return exporter.Write(s.Stdout, content, false)
+	}
+
+	if err := exporter.WriteFile(file, content, false); err != nil {
+		return err
+	}
+
+	if !verify {
+		fmt.Fprintf(s.Stdout, "extracted %d facts to %s\n", len(s.Instance.Facts), file)
+		return nil
+	}
+
+	// DTS が ZIPファイル内にあると書き出した文書からは辿れないので、ファクトだけを読み直して比べる
+	// schemaRef は元のインスタンスからの相対パスのままなので、提出書類を展開した場所に置けば DTS を解決できる
+	var extracted *model.XBRLInstance
+	if parser.InArchive(parser.ResolveHref(s.Instance.Path, s.Instance.SchemaRefs.Href)) {
+		extracted, err = parser.ParseXML[model.XBRLInstance](file)
+	} else {
+		extracted, err = parser.ParseInstance(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to re-parse %s: %v", file, err)
+	}
+	if err := exporter.CompareFacts(s.Instance, extracted); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	fmt.Fprintf(s.Stdout, "extracted %d facts to %s (verified)\n", len(extracted.Facts), file)
+	return nil
+}
@@ -3,12 +3,11 @@ package facts
 import (
 	"flag"
 	"fmt"
-	"strings"
+	"thermal/output"
 	"thermal/parser"
+	"thermal/resolver"
 	"thermal/session"
 	"unicode/utf8"
-
-	"gopkg.in/yaml.v3"
 )
 
 type FactsCommand struct{}
@@ -17,57 +16,55 @@ func New() *FactsCommand {
 	return &FactsCommand{}
 }
 
-func parseArgs(args string) (string, error) {
+func parseArgs(argv []string) (string, string, string, error) {
 	fs := flag.NewFlagSet("facts", flag.ContinueOnError)
 	el := fs.String("e", "", "Pattern to match element names (* = any string)")
-
-	argv := strings.Fields(args)
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en)")
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", err
+		return "", "", "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", "", "", fmt.Errorf("unknown parameter: %v", fs.Args())
 	}
 
-	return *el, nil
-}
-
-func sanitizeLongValue(input string) string {
-	// 改行をすべて削除（CR, LF 両方対応）
-	noNewlines := strings.ReplaceAll(input, "\r", "")
-	noNewlines = strings.ReplaceAll(noNewlines, "\n", "")
-
-	// 先頭100文字を取得（rune単位で安全に）
-	runes := []rune(noNewlines)
-	if len(runes) > 100 {
-		runes = runes[:100]
-		return string(runes) + "…"
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", "", err
 	}
 
-	return noNewlines
+	return *el, *lang, *of, nil
 }
 
 type OutputFact struct {
-	Element    string `yaml:"Element"`
-	ContextRef string `yaml:"Context"`
-	UnitRef    string `yaml:"Unit"`
-	Decimals   string `yaml:"Decimals"`
-	Nil        string `yaml:"Nil"`
-	Length     int    `yaml:"Length"`
-	Value      string `yaml:"Value"`
+	Element        string `yaml:"Element"`
+	Label          string `yaml:"Label,omitempty"`
+	ContextRef     string `yaml:"Context"`
+	UnitRef        string `yaml:"Unit"`
+	Decimals       string `yaml:"Decimals"`
+	Nil            string `yaml:"Nil"`
+	Length         int    `yaml:"Length"`
+	Value          string `yaml:"Value"`
+	TransformError string `yaml:"TransformError,omitempty"`
 }
 
-func (c *FactsCommand) Execute(s *session.Session, args string) {
+func (c *FactsCommand) Execute(s *session.Session, args []string) error {
 	if s.Instance == nil {
-		return
+		return fmt.Errorf("facts requires an instance document")
 	}
 
-	elPattern, err := parseArgs(args)
+	elPattern, lang, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
+	}
+
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
 	}
 
 	var outputFacts []OutputFact
@@ -77,24 +74,23 @@ func (c *FactsCommand) Execute(s *session.Session, args string) {
 			continue
 		}
 
-		val := sanitizeLongValue(fact.Value)
+		val := output.SanitizeLongValue(fact.Value, 100, "…")
 		name := fmt.Sprintf("{%s}%s", fact.XMLName.Space, fact.XMLName.Local)
 		outFact := OutputFact{
-			Element:    name,
-			ContextRef: fact.ContextRef,
-			UnitRef:    fact.UnitRef,
-			Decimals:   fact.Decimals,
-			Nil:        fact.Nil,
-			Length:     utf8.RuneCountInString(fact.Value),
-			Value:      val,
+			Element:        name,
+			ContextRef:     fact.ContextRef,
+			UnitRef:        fact.UnitRef,
+			Decimals:       fact.Decimals,
+			Nil:            fact.Nil,
+			Length:         utf8.RuneCountInString(fact.Value),
+			Value:          val,
+			TransformError: fact.TransformError,
+		}
+		if labels != nil {
+			outFact.Label = labels.LabelByName(fact.XMLName, "", lang)
 		}
 		outputFacts = append(outputFacts, outFact)
 	}
 
-	encoder := yaml.NewEncoder(s.Stdout)
-	encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-	if err := encoder.Encode(outputFacts); err != nil {
-		fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-	}
+	return s.Write(format, outputFacts)
 }
@@ -0,0 +1,139 @@
+package footnotes
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+)
+
+type FootnotesCommand struct{}
+
+func New() *FootnotesCommand {
+	return &FootnotesCommand{}
+}
+
+func parseArgs(argv []string) (string, string, bool, string, error) {
+	fs := flag.NewFlagSet("footnotes", flag.ContinueOnError)
+	el := fs.String("e", "", "Pattern to match element names of annotated facts (* = any string)")
+	tx := fs.String("t", "", "Pattern to match footnote texts (* = any string)")
+	ls := fs.Bool("l", false, "List footnote texts only")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", false, "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", false, "", err
+	}
+
+	return *el, *tx, *ls, *of, nil
+}
+
+type OutputFootnote struct {
+	ID       string               `yaml:"ID"`
+	LinkRole string               `yaml:"LinkRole"`
+	ArcRole  string               `yaml:"ArcRole"`
+	Role     string               `yaml:"Role"`
+	Lang     string               `yaml:"Lang"`
+	Value    string               `yaml:"Footnote"`
+	Facts    []OutputFootnoteFact `yaml:"Facts"`
+}
+
+type OutputFootnoteFact struct {
+	ID         string `yaml:"ID"`
+	Element    string `yaml:"Element"`
+	ContextRef string `yaml:"Context"`
+	UnitRef    string `yaml:"Unit"`
+	Value      string `yaml:"Value"`
+}
+
+func (c *FootnotesCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("footnotes requires an instance document")
+	}
+
+	elPattern, txPattern, ls, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	grouped, err := resolver.TraverseFootnoteLink(s.Instance)
+	if err != nil {
+		return err
+	}
+
+	linkRoles := make([]string, 0, len(grouped))
+	for k := range grouped {
+		linkRoles = append(linkRoles, k)
+	}
+	sort.Strings(linkRoles)
+
+	var outputFootnotes []*OutputFootnote
+
+	for _, linkRole := range linkRoles {
+		// 脚注ごとに、脚注の付いたファクトをまとめる
+		byFootnote := make(map[*model.Footnote]*OutputFootnote)
+		for _, relation := range grouped[linkRole] {
+			// ファクト間の関係（fact-explanatoryFact 等）は対象外
+			footnote, ok := relation.To.(*model.Footnote)
+			if !ok {
+				continue
+			}
+			fact := relation.From.(*model.Fact)
+			arc := relation.Arc.(*model.FootnoteArc)
+
+			if elPattern != "" && !parser.WildcardMatch(elPattern, fact.XMLName.Local) {
+				continue
+			}
+			if txPattern != "" && !parser.WildcardMatch(txPattern, footnote.Value) {
+				continue
+			}
+
+			out, ok := byFootnote[footnote]
+			if !ok {
+				// id属性の無い脚注はラベルで識別する
+				id := footnote.ID
+				if id == "" {
+					id = footnote.Label
+				}
+				out = &OutputFootnote{
+					ID:       id,
+					LinkRole: linkRole,
+					ArcRole:  arc.ArcRole,
+					Role:     footnote.Role,
+					Lang:     footnote.Lang,
+					Value:    strings.TrimSpace(footnote.Value),
+				}
+				byFootnote[footnote] = out
+				outputFootnotes = append(outputFootnotes, out)
+			}
+			out.Facts = append(out.Facts, OutputFootnoteFact{
+				ID:         fact.ID,
+				Element:    fmt.Sprintf("{%s}%s", fact.XMLName.Space, fact.XMLName.Local),
+				ContextRef: fact.ContextRef,
+				UnitRef:    fact.UnitRef,
+				Value:      fact.Value,
+			})
+		}
+	}
+
+	if ls {
+		for _, outputFootnote := range outputFootnotes {
+			fmt.Fprintln(s.Stdout, outputFootnote.Value)
+		}
+	} else {
+		return s.Write(format, outputFootnotes)
+	}
+	return nil
+}
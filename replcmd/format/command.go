@@ -0,0 +1,38 @@
+package format
+
+import (
+	"fmt"
+	"thermal/output"
+	"thermal/session"
+)
+
+type FormatCommand struct{}
+
+func New() *FormatCommand {
+	return &FormatCommand{}
+}
+
+// 全コマンドに共通の出力形式を設定する。引数なしなら現在の設定を表示する
+// 各コマンドの -o 指定はこの設定より優先する
+func (c *FormatCommand) Execute(s *session.Session, args []string) error {
+	switch len(args) {
+	case 0:
+		if s.Format == "" {
+			fmt.Fprintln(s.Stdout, "format: default (yaml, table for table/statement)")
+		} else {
+			fmt.Fprintln(s.Stdout, "format:", s.Format)
+		}
+	case 1:
+		format := args[0]
+		if format == "default" {
+			format = ""
+		}
+		if err := output.ValidateFormat(format); err != nil {
+			return err
+		}
+		s.Format = format
+	default:
+		return fmt.Errorf("unknown parameter: %v", args[1:])
+	}
+	return nil
+}
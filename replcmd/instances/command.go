@@ -23,15 +23,17 @@ func toValidIndex(len int, input string) int {
 	return -1
 }
 
-func (c *InstancesCommand) Execute(s *session.Session, args string) {
+func (c *InstancesCommand) Execute(s *session.Session, args []string) error {
 	if s.Manifest != nil {
-		if args != "" {
-			p := toValidIndex(len(s.Manifest.List.XBRLInstances), args)
+		if len(args) > 1 {
+			return fmt.Errorf("unknown parameter: %v", args[1:])
+		}
+		if len(args) == 1 {
+			p := toValidIndex(len(s.Manifest.List.XBRLInstances), args[0])
 			if p == -1 {
-				fmt.Fprintln(s.Stdout, "invalid number:", args)
-			} else {
-				s.Instance = s.Manifest.List.XBRLInstances[p]
+				return fmt.Errorf("invalid number: %s", args[0])
 			}
+			s.Instance = s.Manifest.List.XBRLInstances[p]
 		}
 		for i, instance := range s.Manifest.List.XBRLInstances {
 			prefix := " "
@@ -48,4 +50,5 @@ func (c *InstancesCommand) Execute(s *session.Session, args string) {
 	} else {
 		fmt.Fprintln(s.Stdout, "no instance.")
 	}
+	return nil
 }
@@ -3,13 +3,11 @@ package labels
 import (
 	"flag"
 	"fmt"
-	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/resolver"
 	"thermal/session"
-
-	"gopkg.in/yaml.v3"
 )
 
 type LabelsCommand struct{}
@@ -18,23 +16,26 @@ func New() *LabelsCommand {
 	return &LabelsCommand{}
 }
 
-func parseArgs(args string) (string, string, bool, error) {
+func parseArgs(argv []string) (string, string, bool, string, error) {
 	fs := flag.NewFlagSet("labels", flag.ContinueOnError)
 	el := fs.String("e", "", "Pattern to match element names (* = any string)")
 	tx := fs.String("t", "", "Pattern to match labels (* = any string)")
 	ls := fs.Bool("l", false, "List labels only")
-
-	argv := strings.Fields(args)
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", "", false, err
+		return "", "", false, "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", false, "", err
 	}
 
-	return *el, *tx, *ls, nil
+	return *el, *tx, *ls, *of, nil
 }
 
 type OutputLabel struct {
@@ -46,18 +47,16 @@ type OutputLabel struct {
 	Href    string `yaml:"Href"`
 }
 
-func (c *LabelsCommand) Execute(s *session.Session, args string) {
+func (c *LabelsCommand) Execute(s *session.Session, args []string) error {
 
-	elPattern, txPattern, ls, err := parseArgs(args)
+	elPattern, txPattern, ls, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	grouped, err := resolver.TraverseLabelLink(s.Schema)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	var outputLabels []OutputLabel
@@ -99,11 +98,7 @@ func (c *LabelsCommand) Execute(s *session.Session, args string) {
 			fmt.Fprintln(s.Stdout, outputLabel.Value)
 		}
 	} else {
-		encoder := yaml.NewEncoder(s.Stdout)
-		encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-		if err := encoder.Encode(outputLabels); err != nil {
-			fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-		}
+		return s.Write(format, outputLabels)
 	}
+	return nil
 }
@@ -0,0 +1,112 @@
+package packages
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/session"
+)
+
+type PackagesCommand struct{}
+
+func New() *PackagesCommand {
+	return &PackagesCommand{}
+}
+
+func parseArgs(argv []string) (string, string, error) {
+	fs := flag.NewFlagSet("packages", flag.ContinueOnError)
+	add := fs.String("a", "", "Load the given taxonomy package (.zip) before listing")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", err
+	}
+
+	return *add, *of, nil
+}
+
+type OutputPackage struct {
+	Path        string             `yaml:"Path"`
+	Identifier  string             `yaml:"Identifier"`
+	Name        string             `yaml:"Name"`
+	Version     string             `yaml:"Version"`
+	Publisher   string             `yaml:"Publisher"`
+	Date        string             `yaml:"PublicationDate"`
+	Rewrites    []string           `yaml:"Rewrites"`
+	EntryPoints []OutputEntryPoint `yaml:"EntryPoints"`
+}
+
+type OutputEntryPoint struct {
+	Name      string   `yaml:"Name"`
+	Documents []string `yaml:"Documents"`
+}
+
+// 言語ごとの文字列のうち、最初の空でないもの
+func text(texts []model.PackageText) string {
+	for _, t := range texts {
+		if v := strings.TrimSpace(t.Value); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c *PackagesCommand) Execute(s *session.Session, args []string) error {
+
+	add, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if add != "" {
+		if _, err := parser.LoadTaxonomyPackage(add); err != nil {
+			return err
+		}
+	}
+
+	pkgs := parser.TaxonomyPackages()
+	if len(pkgs) == 0 && s.OutputFormat(format, output.YAML) == output.YAML {
+		fmt.Fprintln(s.Stdout, "no taxonomy packages.")
+		return nil
+	}
+
+	var outputs []OutputPackage
+	for _, pkg := range pkgs {
+		out := OutputPackage{
+			Path:       pkg.Path,
+			Identifier: strings.TrimSpace(pkg.Identifier),
+			Name:       text(pkg.Names),
+			Version:    strings.TrimSpace(pkg.Version),
+			Publisher:  text(pkg.Publisher),
+			Date:       strings.TrimSpace(pkg.Date),
+		}
+		if pkg.Catalog != nil {
+			for _, rule := range pkg.Catalog.RewriteURIs {
+				out.Rewrites = append(out.Rewrites, rule.StartString+" -> "+rule.RewritePrefix)
+			}
+			for _, rule := range pkg.Catalog.RewriteSystem {
+				out.Rewrites = append(out.Rewrites, rule.StartString+" -> "+rule.RewritePrefix)
+			}
+		}
+		for _, ep := range pkg.EntryPoints {
+			entry := OutputEntryPoint{Name: text(ep.Names)}
+			for _, doc := range ep.Documents {
+				entry.Documents = append(entry.Documents, doc.Href)
+			}
+			out.EntryPoints = append(out.EntryPoints, entry)
+		}
+		outputs = append(outputs, out)
+	}
+	return s.Write(format, outputs)
+}
@@ -5,17 +5,14 @@ import (
 	"flag"
 	"fmt"
 	"maps"
-	"os"
 	"sort"
-	"strconv"
-	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/resolver"
 	"thermal/session"
 
 	"github.com/ddddddO/gtree"
-	"gopkg.in/yaml.v3"
 )
 
 type PresentationsCommand struct{}
@@ -24,22 +21,26 @@ func New() *PresentationsCommand {
 	return &PresentationsCommand{}
 }
 
-func parseArgs(args string) (string, bool, error) {
+func parseArgs(argv []string) (string, string, bool, string, error) {
 	fs := flag.NewFlagSet("presentations", flag.ContinueOnError)
 	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
 	ls := fs.Bool("l", false, "List role type URIs only")
-
-	argv := strings.Fields(args)
+	lang := fs.String("lang", "", "Show labels in the given language (ja/en) instead of element names")
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", false, err
+		return "", "", false, "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", false, "", err
 	}
 
-	return *rt, *ls, nil
+	return *rt, *lang, *ls, *of, nil
 }
 
 type OutputPlesentationLink struct {
@@ -47,18 +48,25 @@ type OutputPlesentationLink struct {
 	ElementTree []string `yaml:"ElementTree"`
 }
 
-func (c *PresentationsCommand) Execute(s *session.Session, args string) {
+func (c *PresentationsCommand) Execute(s *session.Session, args []string) error {
 
-	rtPattern, ls, err := parseArgs(args)
+	rtPattern, lang, ls, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
+	}
+
+	// -lang 指定時は要素名の代わりにラベルを表示する
+	var labels *resolver.LabelResolver
+	if lang != "" {
+		labels, err = s.Labels()
+		if err != nil {
+			return err
+		}
 	}
 
 	grouped, err := resolver.TraversePresentationLink(s.Schema)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	arcRoles := make([]string, 0, len(grouped))
@@ -71,8 +79,7 @@ func (c *PresentationsCommand) Execute(s *session.Session, args string) {
 
 	// ロールタイプのフィルタ指定があり、マッチしたのがなかったらエラー
 	if rtPattern != "" && len(arcRoles) == 0 {
-		fmt.Fprintf(s.Stdout, "roleType not found. %s \n", rtPattern)
-		return
+		return fmt.Errorf("roleType not found: %s", rtPattern)
 	}
 
 	sort.Strings(arcRoles)
@@ -92,13 +99,12 @@ func (c *PresentationsCommand) Execute(s *session.Session, args string) {
 			var trees []string
 			for _, root := range roots {
 				e := root.(*model.XMLElement)
-				groot := gtree.NewRoot(fmt.Sprintf("Root,%s", e.Name))
-				dfs(e, visited, adj, groot)
+				groot := gtree.NewRoot(fmt.Sprintf("Root,%s", labels.DisplayName(e, "", lang)))
+				dfs(e, visited, adj, groot, labels, lang)
 
 				var buf bytes.Buffer
 				if err := gtree.OutputFromRoot(&buf, groot); err != nil {
-					fmt.Fprintln(s.Stderr, "error:", err)
-					return
+					return err
 				}
 				trees = append(trees, buf.String())
 			}
@@ -107,16 +113,12 @@ func (c *PresentationsCommand) Execute(s *session.Session, args string) {
 				ElementTree: trees,
 			})
 		}
-		encoder := yaml.NewEncoder(os.Stdout)
-		encoder.SetIndent(2) // 読みやすさのためにインデント設定
-
-		if err := encoder.Encode(outputElements); err != nil {
-			fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-		}
+		return s.Write(format, outputElements)
 	}
+	return nil
 }
 
-func dfs(node *model.XMLElement, visited map[*model.XMLElement]bool, adj map[any][]*resolver.ArcRelation, gnode *gtree.Node) {
+func dfs(node *model.XMLElement, visited map[*model.XMLElement]bool, adj map[any][]*resolver.ArcRelation, gnode *gtree.Node, labels *resolver.LabelResolver, lang string) {
 	if visited[node] {
 		return
 	}
@@ -124,30 +126,18 @@ func dfs(node *model.XMLElement, visited map[*model.XMLElement]bool, adj map[any
 
 	relations := adj[node]
 	sort.Slice(relations, func(i, j int) bool {
-		arci := relations[i].Arc.(*model.PresentationArc)
-		fi, err := strconv.ParseFloat(arci.Order, 64)
-		if err != nil {
-			fi = 1.0
-		}
-
-		arcj := relations[j].Arc.(*model.PresentationArc)
-		fj, err := strconv.ParseFloat(arcj.Order, 64)
-		if err != nil {
-			fj = 1.0
-		}
-
-		return fi < fj
+		return resolver.ArcOrder(relations[i]) < resolver.ArcOrder(relations[j])
 	})
 
 	for _, child := range relations {
 		to := child.To.(*model.XMLElement)
 		arc := child.Arc.(*model.PresentationArc)
-		text := fmt.Sprintf("%s,%s,%s", arc.Order, to.Name, arc.PreferredLabel)
+		text := fmt.Sprintf("%s,%s,%s", arc.Order, labels.DisplayName(to, arc.PreferredLabel, lang), arc.PreferredLabel)
 		gnodec := gnode.Add(text)
 
 		visitedCopy := make(map[*model.XMLElement]bool)
 		maps.Copy(visitedCopy, visited)
 
-		dfs(to, visitedCopy, adj, gnodec)
+		dfs(to, visitedCopy, adj, gnodec, labels, lang)
 	}
 }
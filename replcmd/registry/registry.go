@@ -3,21 +3,41 @@ package registry
 import (
 	"fmt"
 	"strings"
+	"thermal/replcmd/cache"
+	"thermal/replcmd/calccheck"
+	"thermal/replcmd/calculations"
 	"thermal/replcmd/contexts"
 	"thermal/replcmd/definitions"
+	"thermal/replcmd/diagnostics"
+	"thermal/replcmd/dimcheck"
+	"thermal/replcmd/dimensions"
 	"thermal/replcmd/dts"
 	"thermal/replcmd/elements"
+	"thermal/replcmd/export"
+	"thermal/replcmd/extract"
 	"thermal/replcmd/facts"
+	"thermal/replcmd/footnotes"
+	"thermal/replcmd/format"
 	"thermal/replcmd/instances"
 	"thermal/replcmd/labels"
+	"thermal/replcmd/packages"
 	"thermal/replcmd/presentations"
 	"thermal/replcmd/references"
 	"thermal/replcmd/roletypes"
+	"thermal/replcmd/statement"
+	"thermal/replcmd/table"
+	"thermal/replcmd/units"
 	"thermal/session"
 )
 
+// 引数を受け取って実行し、失敗したときはエラーを返す
 type Command interface {
-	Execute(*session.Session, string)
+	Execute(*session.Session, []string) error
+}
+
+// エントリーファイルを読み込まずに実行できるコマンド（cache など）
+type EntryLess interface {
+	EntryLess() bool
 }
 
 var commandMap = map[string]Command{}
@@ -30,31 +50,75 @@ func RegisterAll() {
 	commandMap["references"] = references.New()
 	commandMap["presentations"] = presentations.New()
 	commandMap["definitions"] = definitions.New()
+	commandMap["calculations"] = calculations.New()
+	commandMap["calccheck"] = calccheck.New()
+	commandMap["dimensions"] = dimensions.New()
+	commandMap["dimcheck"] = dimcheck.New()
 	commandMap["dts"] = dts.New()
 	commandMap["roletypes"] = roletypes.New()
 	commandMap["instances"] = instances.New()
+	commandMap["footnotes"] = footnotes.New()
+	commandMap["units"] = units.New()
+	commandMap["table"] = table.New()
+	commandMap["statement"] = statement.New()
+	commandMap["export"] = export.New()
+	commandMap["format"] = format.New()
+	commandMap["extract"] = extract.New()
+	commandMap["packages"] = packages.New()
+	commandMap["cache"] = cache.New()
+	commandMap["diagnostics"] = diagnostics.New()
 
 	// エイリアス
 	commandMap["pr"] = commandMap["presentations"]
 	commandMap["df"] = commandMap["definitions"]
+	commandMap["cl"] = commandMap["calculations"]
+	commandMap["cc"] = commandMap["calccheck"]
+	commandMap["dm"] = commandMap["dimensions"]
+	commandMap["dc"] = commandMap["dimcheck"]
 	commandMap["rt"] = commandMap["roletypes"]
 	commandMap["el"] = commandMap["elements"]
 	commandMap["lb"] = commandMap["labels"]
 	commandMap["rf"] = commandMap["references"]
 	commandMap["cx"] = commandMap["contexts"]
 	commandMap["in"] = commandMap["instances"]
+	commandMap["fn"] = commandMap["footnotes"]
+	commandMap["un"] = commandMap["units"]
+	commandMap["tb"] = commandMap["table"]
+	commandMap["st"] = commandMap["statement"]
+	commandMap["ex"] = commandMap["export"]
+	commandMap["fm"] = commandMap["format"]
+	commandMap["xt"] = commandMap["extract"]
+	commandMap["pk"] = commandMap["packages"]
+	commandMap["ca"] = commandMap["cache"]
+	commandMap["dg"] = commandMap["diagnostics"]
+}
+
+// 名前又はエイリアスでコマンドを探す
+func Lookup(name string) (Command, bool) {
+	c, ok := commandMap[name]
+	return c, ok
 }
 
+// エントリーファイルの読込みが要らないコマンドか
+func IsEntryLess(c Command) bool {
+	e, ok := c.(EntryLess)
+	return ok && e.EntryLess()
+}
+
+// 入力行を空白で区切って実行する（REPL 用）
 func Execute(input string, s *session.Session) {
 	fields := strings.Fields(input)
 	if len(fields) == 0 {
 		return
 	}
 
-	cmd, args := fields[0], strings.Join(fields[1:], " ")
-	if c, ok := commandMap[cmd]; ok {
-		c.Execute(s, args)
-	} else {
+	cmd, args := fields[0], fields[1:]
+	c, ok := commandMap[cmd]
+	if !ok {
 		fmt.Fprintln(s.Stderr, "Unknown command:", cmd)
+		return
+	}
+	if err := c.Execute(s, args); err != nil {
+		fmt.Fprintln(s.Stderr, "error:", err)
 	}
 }
@@ -4,13 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"sort"
-	"strings"
 	"thermal/model"
+	"thermal/output"
 	"thermal/parser"
 	"thermal/resolver"
 	"thermal/session"
-
-	"gopkg.in/yaml.v3"
 )
 
 type RoleTypesCommand struct{}
@@ -19,30 +17,32 @@ func New() *RoleTypesCommand {
 	return &RoleTypesCommand{}
 }
 
-func parseArgs(args string) (string, bool, error) {
+func parseArgs(argv []string) (string, bool, string, error) {
 	fs := flag.NewFlagSet("roletypes", flag.ContinueOnError)
 	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
 	ls := fs.Bool("l", false, "List role type URIs only")
-
-	argv := strings.Fields(args)
+	of := fs.String("o", "", output.FormatUsage)
 
 	if err := fs.Parse(argv); err != nil {
-		return "", false, err
+		return "", false, "", err
 	}
 
 	if fs.NArg() > 0 {
-		return "", false, fmt.Errorf("unknown parameter: %v", fs.Args())
+		return "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
 	}
 
-	return *rt, *ls, nil
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", false, "", err
+	}
+
+	return *rt, *ls, *of, nil
 }
 
-func (c *RoleTypesCommand) Execute(s *session.Session, args string) {
+func (c *RoleTypesCommand) Execute(s *session.Session, args []string) error {
 
-	rtPattern, ls, err := parseArgs(args)
+	rtPattern, ls, format, err := parseArgs(args)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	allRoleTypes := make(map[string]*model.RoleType)
@@ -50,8 +50,7 @@ func (c *RoleTypesCommand) Execute(s *session.Session, args string) {
 
 	grouped, err := resolver.TraverseGenericLink(s.Schema, allRoleTypes)
 	if err != nil {
-		fmt.Fprintln(s.Stderr, "error:", err)
-		return
+		return err
 	}
 
 	hrefs := make([]string, 0, len(allRoleTypes))
@@ -64,8 +63,7 @@ func (c *RoleTypesCommand) Execute(s *session.Session, args string) {
 
 	// ロールタイプのフィルタ指定があり、マッチしたのがなかったらエラー
 	if rtPattern != "" && len(hrefs) == 0 {
-		fmt.Fprintf(s.Stdout, "roleType not found. %s \n", rtPattern)
-		return
+		return fmt.Errorf("roleType not found: %s", rtPattern)
 	}
 
 	sort.Strings(hrefs)
@@ -98,12 +96,9 @@ func (c *RoleTypesCommand) Execute(s *session.Session, args string) {
 			outputRoleTypes[i] = rt
 		}
 
-		encoder := yaml.NewEncoder(s.Stdout)
-		encoder.SetIndent(2) // 読みやすさのためにインデント設定
-		if err := encoder.Encode(outputRoleTypes); err != nil {
-			fmt.Fprintf(s.Stderr, "YAML encode error: %v\n", err)
-		}
+		return s.Write(format, outputRoleTypes)
 	}
+	return nil
 }
 
 type OutputRoleType struct {
@@ -0,0 +1,346 @@
+package statement
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+	"time"
+)
+
+type StatementCommand struct{}
+
+func New() *StatementCommand {
+	return &StatementCommand{}
+}
+
+func parseArgs(argv []string) (string, string, string, error) {
+	fs := flag.NewFlagSet("statement", flag.ContinueOnError)
+	rt := fs.String("r", "", "Pattern to match role type URIs (* = any string)")
+	lang := fs.String("lang", "ja", "Label language (ja/en)")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", "", "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", "", "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if *rt == "" {
+		return "", "", "", fmt.Errorf("role type pattern (-r) is required")
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", "", "", err
+	}
+
+	return *rt, *lang, *of, nil
+}
+
+// 表示リンクの1行分
+type statementLine struct {
+	element        *model.XMLElement
+	depth          int
+	preferredLabel string
+}
+
+// 列（期間）
+type column struct {
+	instant   string
+	startDate string
+	endDate   string
+}
+
+func (c column) header() string {
+	if c.instant != "" {
+		return c.instant
+	}
+	return c.startDate + "/" + c.endDate
+}
+
+func (c *StatementCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("statement requires an instance document")
+	}
+
+	rtPattern, lang, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	grouped, err := resolver.TraversePresentationLink(s.Schema)
+	if err != nil {
+		return err
+	}
+
+	arcRoles := make([]string, 0, len(grouped))
+	for k := range grouped {
+		if parser.WildcardMatch(rtPattern, k) {
+			arcRoles = append(arcRoles, k)
+		}
+	}
+
+	if len(arcRoles) == 0 {
+		return fmt.Errorf("roleType not found: %s", rtPattern)
+	}
+
+	sort.Strings(arcRoles)
+
+	labels, err := s.Labels()
+	if err != nil {
+		return err
+	}
+
+	facts := collectFacts(s.Instance)
+
+	// 表以外の形式では、全ELRの行を RoleType 列付きでまとめて出力する
+	format = s.OutputFormat(format, output.Table)
+	allHeader := []string{"RoleType"}
+	allColumns := map[string]int{}
+	var allRows [][]string
+
+	for i, arcRole := range arcRoles {
+		lines := buildLines(grouped[arcRole])
+		columns := buildColumns(lines, facts)
+
+		header := []string{"Label"}
+		for _, col := range columns {
+			header = append(header, col.header())
+		}
+
+		var rows [][]string
+		for _, line := range lines {
+			label := labels.LabelOrName(line.element, line.preferredLabel, lang)
+			row := []string{strings.Repeat("  ", line.depth) + label}
+
+			for _, col := range columns {
+				fact := lookupFact(facts, line, col)
+				row = append(row, formatValue(fact, isNegated(line.preferredLabel)))
+			}
+			rows = append(rows, row)
+		}
+
+		if format != output.Table {
+			for _, h := range header {
+				if _, ok := allColumns[h]; !ok {
+					allColumns[h] = len(allHeader)
+					allHeader = append(allHeader, h)
+				}
+			}
+			for _, row := range rows {
+				record := make([]string, len(allHeader))
+				record[0] = arcRole
+				for j, cell := range row {
+					record[allColumns[header[j]]] = cell
+				}
+				allRows = append(allRows, record)
+			}
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(s.Stdout)
+		}
+		fmt.Fprintf(s.Stdout, "[%s]\n", arcRole)
+		if err := output.WriteTable(s.Stdout, header, rows); err != nil {
+			return err
+		}
+	}
+
+	if format != output.Table {
+		return s.WriteRows(format, allHeader, allRows)
+	}
+	return nil
+}
+
+// 表示リンクを order 順にたどり、行の並びにする
+func buildLines(relations []resolver.ArcRelation) []statementLine {
+	roots := resolver.FindRootNodes(relations)
+	adj := resolver.BuildAdjacency(relations)
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].(*model.XMLElement).Name < roots[j].(*model.XMLElement).Name
+	})
+
+	var lines []statementLine
+	var dfs func(node *model.XMLElement, depth int, preferredLabel string, visited map[*model.XMLElement]bool)
+	dfs = func(node *model.XMLElement, depth int, preferredLabel string, visited map[*model.XMLElement]bool) {
+		if visited[node] {
+			return
+		}
+		visited[node] = true
+		defer delete(visited, node)
+
+		lines = append(lines, statementLine{element: node, depth: depth, preferredLabel: preferredLabel})
+
+		children := adj[node]
+		sort.SliceStable(children, func(i, j int) bool {
+			return resolver.ArcOrder(children[i]) < resolver.ArcOrder(children[j])
+		})
+		for _, child := range children {
+			arc := child.Arc.(*model.PresentationArc)
+			dfs(child.To.(*model.XMLElement), depth+1, arc.PreferredLabel, visited)
+		}
+	}
+
+	for _, root := range roots {
+		dfs(root.(*model.XMLElement), 0, "", map[*model.XMLElement]bool{})
+	}
+	return lines
+}
+
+// ディメンションの無いコンテキストのファクトを (要素, 期間) ごとにまとめる
+func collectFacts(instance *model.XBRLInstance) map[xml.Name]map[column]*model.Fact {
+	contexts := make(map[string]*model.Context, len(instance.Contexts))
+	for i := range instance.Contexts {
+		contexts[instance.Contexts[i].ID] = &instance.Contexts[i]
+	}
+
+	facts := make(map[xml.Name]map[column]*model.Fact)
+	for i := range instance.Facts {
+		fact := &instance.Facts[i]
+		context, ok := contexts[fact.ContextRef]
+		if !ok || len(context.Dimensions()) > 0 {
+			continue
+		}
+		col := column{
+			instant:   strings.TrimSpace(context.Period.Instant),
+			startDate: strings.TrimSpace(context.Period.StartDate),
+			endDate:   strings.TrimSpace(context.Period.EndDate),
+		}
+		if facts[fact.XMLName] == nil {
+			facts[fact.XMLName] = make(map[column]*model.Fact)
+		}
+		if _, exists := facts[fact.XMLName][col]; !exists {
+			facts[fact.XMLName][col] = fact
+		}
+	}
+	return facts
+}
+
+// 列を決める。期間（duration）のファクトがあれば期間ごと、無ければ時点ごとの列にする
+func buildColumns(lines []statementLine, facts map[xml.Name]map[column]*model.Fact) []column {
+	durations := make(map[column]bool)
+	instants := make(map[column]bool)
+	for _, line := range lines {
+		for col := range facts[elementName(line.element)] {
+			if col.instant != "" {
+				instants[col] = true
+			} else {
+				durations[col] = true
+			}
+		}
+	}
+
+	selected := instants
+	if len(durations) > 0 {
+		selected = durations
+	}
+
+	columns := make([]column, 0, len(selected))
+	for col := range selected {
+		columns = append(columns, col)
+	}
+	// 古い期間から順に並べる
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].header() < columns[j].header()
+	})
+	return columns
+}
+
+// 列に対応するファクトを探す。期間の列では、時点の要素は期末（periodStart の場合は期首）の値を使う
+func lookupFact(facts map[xml.Name]map[column]*model.Fact, line statementLine, col column) *model.Fact {
+	byPeriod := facts[elementName(line.element)]
+	if fact, ok := byPeriod[col]; ok {
+		return fact
+	}
+	if col.instant != "" {
+		return nil
+	}
+
+	instant := col.endDate
+	if isPeriodStart(line.preferredLabel) {
+		// 期首の時点は開始日の前日
+		start, err := time.Parse("2006-01-02", col.startDate)
+		if err != nil {
+			return nil
+		}
+		instant = start.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	return byPeriod[column{instant: instant}]
+}
+
+func elementName(elem *model.XMLElement) xml.Name {
+	return xml.Name{Space: elem.Schema.TargetNS, Local: elem.Name}
+}
+
+// periodStartLabel・negatedPeriodStartLabel 等の期首のラベルロールか判定する
+func isPeriodStart(preferredLabel string) bool {
+	i := strings.LastIndex(preferredLabel, "/")
+	return strings.Contains(strings.ToLower(preferredLabel[i+1:]), "periodstart")
+}
+
+// negatedLabel 等のラベルロールでは符号を反転して表示する
+func isNegated(preferredLabel string) bool {
+	i := strings.LastIndex(preferredLabel, "/")
+	return strings.HasPrefix(preferredLabel[i+1:], "negated")
+}
+
+func formatValue(fact *model.Fact, negated bool) string {
+	if fact == nil {
+		return ""
+	}
+	if fact.Nil == "true" {
+		return "-"
+	}
+
+	value := strings.TrimSpace(fact.Value)
+	if fact.UnitRef == "" {
+		return output.SanitizeLongValue(value, 30, "…")
+	}
+
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return value
+	}
+	if negated {
+		r.Neg(r)
+	}
+
+	// 小数点以下の桁数は元の値に合わせる
+	scale := 0
+	if i := strings.Index(value, "."); i >= 0 {
+		scale = len(value) - i - 1
+	}
+	return groupThousands(r.FloatString(scale))
+}
+
+// 整数部を3桁ごとにカンマで区切る
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		integer, fraction = s[:i], s[i:]
+	}
+
+	var sb strings.Builder
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteRune(r)
+	}
+	return sign + sb.String() + fraction
+}
@@ -0,0 +1,140 @@
+package table
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"thermal/model"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/resolver"
+	"thermal/session"
+)
+
+type TableCommand struct{}
+
+func New() *TableCommand {
+	return &TableCommand{}
+}
+
+type tableArgs struct {
+	elPattern string
+	cxPattern string
+	lang      string
+	role      string
+	format    string
+}
+
+func parseArgs(argv []string) (tableArgs, error) {
+	fs := flag.NewFlagSet("table", flag.ContinueOnError)
+	el := fs.String("e", "", "Pattern to match element names (* = any string)")
+	cx := fs.String("c", "", "Pattern to match context IDs (* = any string)")
+	lang := fs.String("lang", "ja", "Label language (ja/en)")
+	role := fs.String("role", "label", "Label role (e.g. label, verbose, terse, or a role URI)")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return tableArgs{}, err
+	}
+
+	if fs.NArg() > 0 {
+		return tableArgs{}, fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return tableArgs{}, err
+	}
+
+	return tableArgs{
+		elPattern: *el,
+		cxPattern: *cx,
+		lang:      *lang,
+		role:      resolver.ExpandLabelRole(*role),
+		format:    *of,
+	}, nil
+}
+
+func (c *TableCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("table requires an instance document")
+	}
+
+	a, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	labels, err := s.Labels()
+	if err != nil {
+		return err
+	}
+
+	contexts := make(map[string]*model.Context, len(s.Instance.Contexts))
+	for i := range s.Instance.Contexts {
+		contexts[s.Instance.Contexts[i].ID] = &s.Instance.Contexts[i]
+	}
+	units := make(map[string]*model.Unit, len(s.Instance.Units))
+	for i := range s.Instance.Units {
+		units[s.Instance.Units[i].ID] = &s.Instance.Units[i]
+	}
+
+	header := []string{"Label", "Period", "Entity", "Dimensions", "Unit", "Decimals", "Value"}
+	var rows [][]string
+
+	for _, fact := range s.Instance.Facts {
+		if a.elPattern != "" && !parser.WildcardMatch(a.elPattern, fact.XMLName.Local) {
+			continue
+		}
+		if a.cxPattern != "" && !parser.WildcardMatch(a.cxPattern, fact.ContextRef) {
+			continue
+		}
+
+		label := labels.LabelByName(fact.XMLName, a.role, a.lang)
+		if label == "" {
+			label = fact.XMLName.Local
+		}
+
+		var period, entity, dimensions string
+		if context, ok := contexts[fact.ContextRef]; ok {
+			period = formatPeriod(context.Period)
+			entity = strings.TrimSpace(context.Entity.Identifier.Value)
+			var dims []string
+			for _, dim := range context.Dimensions() {
+				dims = append(dims, fmt.Sprintf("%s=%s", dim.Dimension, dim.Member))
+			}
+			dimensions = strings.Join(dims, ", ")
+		}
+
+		unit := fact.UnitRef
+		if u, ok := units[fact.UnitRef]; ok {
+			unit = u.Measure()
+		}
+
+		value := fact.Value
+		if fact.Nil == "true" {
+			value = "(nil)"
+		}
+
+		rows = append(rows, []string{
+			label,
+			period,
+			entity,
+			dimensions,
+			unit,
+			fact.Decimals,
+			output.SanitizeLongValue(value, 50, "…"),
+		})
+	}
+
+	return s.WriteRows(a.format, header, rows)
+}
+
+func formatPeriod(period model.Period) string {
+	if period.Instant != "" {
+		return strings.TrimSpace(period.Instant)
+	}
+	if period.StartDate != "" || period.EndDate != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimSpace(period.StartDate), strings.TrimSpace(period.EndDate))
+	}
+	return "forever"
+}
@@ -0,0 +1,96 @@
+package units
+
+import (
+	"flag"
+	"fmt"
+	"thermal/output"
+	"thermal/parser"
+	"thermal/session"
+)
+
+type UnitsCommand struct{}
+
+func New() *UnitsCommand {
+	return &UnitsCommand{}
+}
+
+func parseArgs(argv []string) (string, bool, string, error) {
+	fs := flag.NewFlagSet("units", flag.ContinueOnError)
+	un := fs.String("u", "", "Pattern to match unit IDs (* = any string)")
+	ls := fs.Bool("l", false, "List unit IDs only")
+	of := fs.String("o", "", output.FormatUsage)
+
+	if err := fs.Parse(argv); err != nil {
+		return "", false, "", err
+	}
+
+	if fs.NArg() > 0 {
+		return "", false, "", fmt.Errorf("unknown parameter: %v", fs.Args())
+	}
+
+	if err := output.ValidateFormat(*of); err != nil {
+		return "", false, "", err
+	}
+
+	return *un, *ls, *of, nil
+}
+
+type OutputUnit struct {
+	ID          string   `yaml:"ID"`
+	Measure     string   `yaml:"Measure"`
+	Numerator   []string `yaml:"Numerator"`
+	Denominator []string `yaml:"Denominator"`
+	Canonical   string   `yaml:"Canonical"`
+	Facts       int      `yaml:"Facts"`
+}
+
+func (c *UnitsCommand) Execute(s *session.Session, args []string) error {
+	if s.Instance == nil {
+		return fmt.Errorf("units requires an instance document")
+	}
+
+	unPattern, ls, format, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	// 単位ごとのファクト数
+	factCounts := make(map[string]int)
+	for _, fact := range s.Instance.Facts {
+		if fact.UnitRef != "" {
+			factCounts[fact.UnitRef]++
+		}
+	}
+
+	var outputUnits []OutputUnit
+
+	for _, unit := range s.Instance.Units {
+		if unPattern != "" && !parser.WildcardMatch(unPattern, unit.ID) {
+			continue
+		}
+
+		if ls {
+			fmt.Fprintln(s.Stdout, unit.ID)
+			continue
+		}
+
+		outUnit := OutputUnit{
+			ID:        unit.ID,
+			Measure:   unit.Measure(),
+			Canonical: unit.Canonical(),
+			Facts:     factCounts[unit.ID],
+		}
+		for _, m := range unit.Numerator {
+			outUnit.Numerator = append(outUnit.Numerator, fmt.Sprintf("{%s}%s", m.Space, m.Local))
+		}
+		for _, m := range unit.Denominator {
+			outUnit.Denominator = append(outUnit.Denominator, fmt.Sprintf("{%s}%s", m.Space, m.Local))
+		}
+		outputUnits = append(outputUnits, outUnit)
+	}
+
+	if !ls {
+		return s.Write(format, outputUnits)
+	}
+	return nil
+}
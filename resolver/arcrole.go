@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"slices"
+	"strings"
+)
+
+// 定義リンクのアークロール
+const (
+	ArcRoleAll                = "http://xbrl.org/int/dim/arcrole/all"
+	ArcRoleNotAll             = "http://xbrl.org/int/dim/arcrole/notAll"
+	ArcRoleHypercubeDimension = "http://xbrl.org/int/dim/arcrole/hypercube-dimension"
+	ArcRoleDimensionDomain    = "http://xbrl.org/int/dim/arcrole/dimension-domain"
+	ArcRoleDomainMember       = "http://xbrl.org/int/dim/arcrole/domain-member"
+	ArcRoleDimensionDefault   = "http://xbrl.org/int/dim/arcrole/dimension-default"
+	ArcRoleGeneralSpecial     = "http://www.xbrl.org/2003/arcrole/general-special"
+	ArcRoleEssenceAlias       = "http://www.xbrl.org/2003/arcrole/essence-alias"
+	ArcRoleSimilarTuples      = "http://www.xbrl.org/2003/arcrole/similar-tuples"
+	ArcRoleRequiresElement    = "http://www.xbrl.org/2003/arcrole/requires-element"
+)
+
+// アークロールURIの末尾（例: http://xbrl.org/int/dim/arcrole/all → all）
+func ShortArcRole(arcRole string) string {
+	return arcRole[strings.LastIndex(arcRole, "/")+1:]
+}
+
+// XDTの連続する関係で、各アークロールの後に続けられるアークロール
+var consecutiveArcRoles = map[string][]string{
+	ArcRoleAll:                {ArcRoleHypercubeDimension},
+	ArcRoleNotAll:             {ArcRoleHypercubeDimension},
+	ArcRoleHypercubeDimension: {ArcRoleDimensionDomain, ArcRoleDimensionDefault},
+	ArcRoleDimensionDomain:    {ArcRoleDomainMember},
+	ArcRoleDomainMember:       {ArcRoleDomainMember, ArcRoleAll, ArcRoleNotAll},
+	ArcRoleDimensionDefault:   {},
+}
+
+// prev のアークの先で next のアークをたどれるか。XDT以外のアークロールは制限しない
+func IsConsecutiveArcRole(prev, next string) bool {
+	allowed, ok := consecutiveArcRoles[prev]
+	if !ok {
+		_, isXDT := consecutiveArcRoles[next]
+		return !isXDT || prev == ""
+	}
+	return slices.Contains(allowed, next)
+}
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"encoding/xml"
 	"thermal/model"
 )
 
@@ -29,3 +30,54 @@ func CollectRoleTypesByHref(schema *model.XBRLSchema, result map[string]*model.R
 		}
 	}
 }
+
+// DTSの全要素を名前空間付きの名前をキーにしたmapにまとめる
+func CollectElementsByName(schema *model.XBRLSchema, result map[xml.Name]*model.XMLElement) {
+	for i, element := range schema.Elements {
+		key := xml.Name{Space: schema.TargetNS, Local: element.Name}
+		result[key] = &schema.Elements[i]
+	}
+	for i := range schema.Imports {
+		if schema.Imports[i].Schema != nil {
+			CollectElementsByName(schema.Imports[i].Schema, result)
+		}
+	}
+}
+
+// DTSの名称リンクベースの全名称をmapにまとめる
+func CollectLabelsByHref(schema *model.XBRLSchema, result map[string]*model.LabelLabel) {
+	for _, linkbase := range schema.ReferencedLabelLinkbases {
+		for i := range linkbase.LabelLinks {
+			labels := linkbase.LabelLinks[i].Labels
+			for j := range labels {
+				if labels[j].Id != "" {
+					result[linkbase.Path+"#"+labels[j].Id] = &labels[j]
+				}
+			}
+		}
+	}
+	for i := range schema.Imports {
+		if schema.Imports[i].Schema != nil {
+			CollectLabelsByHref(schema.Imports[i].Schema, result)
+		}
+	}
+}
+
+// DTSの参照リンクベースの全参照をmapにまとめる
+func CollectReferencesByHref(schema *model.XBRLSchema, result map[string]*model.ReferenceReference) {
+	for _, linkbase := range schema.ReferencedReferenceLinkbases {
+		for i := range linkbase.ReferenceLinks {
+			references := linkbase.ReferenceLinks[i].References
+			for j := range references {
+				if references[j].Id != "" {
+					result[linkbase.Path+"#"+references[j].Id] = &references[j]
+				}
+			}
+		}
+	}
+	for i := range schema.Imports {
+		if schema.Imports[i].Schema != nil {
+			CollectReferencesByHref(schema.Imports[i].Schema, result)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package resolver
+
+import (
+	"sort"
+	"thermal/model"
+	"thermal/parser"
+)
+
+// 🧊 ハイパーキューブ（has-hypercube 関係1件分）
+type Hypercube struct {
+	Role           string            // has-hypercube 関係のELR
+	PrimaryItem    *model.XMLElement // has-hypercube 関係の起点の基本項目
+	Element        *model.XMLElement
+	ArcRole        string // all 又は notAll
+	Closed         bool
+	ContextElement string // segment 又は scenario
+	Dimensions     []*Dimension
+}
+
+// 🧊 ハイパーキューブのディメンション
+type Dimension struct {
+	Element     *model.XMLElement
+	Role        string            // hypercube-dimension 関係をたどったELR
+	TypedDomain *model.XMLElement // 型付きディメンションのドメイン要素（明示的ディメンションは nil）
+	Domains     []*DomainMember   // dimension-domain 関係の先
+	Default     *model.XMLElement // dimension-default（無ければ nil）
+}
+
+// 🧊 ドメインのメンバー
+type DomainMember struct {
+	Element  *model.XMLElement
+	Role     string // この関係をたどったELR
+	Usable   bool
+	Children []*DomainMember
+}
+
+// 🧊 基本項目と、継承分を含めて適用されるハイパーキューブ
+type PrimaryItem struct {
+	Element    *model.XMLElement
+	Depth      int
+	Hypercubes []*Hypercube
+}
+
+// 🧊 ELRごとのディメンション構造
+type DimensionalELR struct {
+	Role         string
+	PrimaryItems []*PrimaryItem
+	Hypercubes   []*Hypercube
+}
+
+// 🧊 DTS全体のディメンション構造
+type DimensionalModel struct {
+	ELRs     []*DimensionalELR
+	Defaults map[*model.XMLElement]*model.XMLElement // ディメンション → 既定メンバー
+}
+
+// ELRとアークロールごとの隣接リスト
+type definitionNetwork map[string]map[*model.XMLElement][]*ArcRelation
+
+func (n definitionNetwork) children(role string, elem *model.XMLElement, arcRole string) []*ArcRelation {
+	var result []*ArcRelation
+	for _, rel := range n[role][elem] {
+		if rel.Arc.(*model.DefinitionArc).ArcRole == arcRole {
+			result = append(result, rel)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return ArcOrder(result[i]) < ArcOrder(result[j])
+	})
+	return result
+}
+
+// targetRole があればそのELR、無ければ同じELRで続きをたどる
+func nextRole(role string, rel *ArcRelation) string {
+	if target := rel.Arc.(*model.DefinitionArc).TargetRole; target != "" {
+		return target
+	}
+	return role
+}
+
+// 定義リンクからディメンション構造を組み立てる（XDT 1.0）
+func BuildDimensionalModel(schema *model.XBRLSchema) (*DimensionalModel, error) {
+	grouped, err := TraverseDefinitionLink(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	typedDomains := make(map[string]*model.XMLElement)
+	CollectElementsByHref(schema, typedDomains)
+
+	network := make(definitionNetwork, len(grouped))
+	m := &DimensionalModel{Defaults: make(map[*model.XMLElement]*model.XMLElement)}
+	for role, relations := range grouped {
+		network[role] = make(map[*model.XMLElement][]*ArcRelation)
+		for i := range relations {
+			rel := &relations[i]
+			from := rel.From.(*model.XMLElement)
+			network[role][from] = append(network[role][from], rel)
+			// dimension-default はELRによらずDTS全体で有効
+			if rel.Arc.(*model.DefinitionArc).ArcRole == ArcRoleDimensionDefault {
+				m.Defaults[from] = rel.To.(*model.XMLElement)
+			}
+		}
+	}
+
+	roles := make([]string, 0, len(grouped))
+	for role := range grouped {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		elr := buildDimensionalELR(role, grouped[role], network, m.Defaults, typedDomains)
+		if len(elr.Hypercubes) > 0 {
+			m.ELRs = append(m.ELRs, elr)
+		}
+	}
+	return m, nil
+}
+
+func buildDimensionalELR(role string, relations []ArcRelation, network definitionNetwork, defaults map[*model.XMLElement]*model.XMLElement, typedDomains map[string]*model.XMLElement) *DimensionalELR {
+	elr := &DimensionalELR{Role: role}
+
+	// has-hypercube 関係を基本項目ごとにまとめる
+	hypercubes := make(map[*model.XMLElement][]*Hypercube)
+	for i := range relations {
+		rel := &relations[i]
+		arc := rel.Arc.(*model.DefinitionArc)
+		if arc.ArcRole != ArcRoleAll && arc.ArcRole != ArcRoleNotAll {
+			continue
+		}
+		primary := rel.From.(*model.XMLElement)
+		hc := &Hypercube{
+			Role:           role,
+			PrimaryItem:    primary,
+			Element:        rel.To.(*model.XMLElement),
+			ArcRole:        arc.ArcRole,
+			Closed:         arc.Closed == "true" || arc.Closed == "1",
+			ContextElement: arc.ContextElement,
+		}
+		hcRole := nextRole(role, rel)
+		for _, hd := range network.children(hcRole, hc.Element, ArcRoleHypercubeDimension) {
+			hc.Dimensions = append(hc.Dimensions, buildDimension(nextRole(hcRole, hd), hd, network, defaults, typedDomains))
+		}
+		hypercubes[primary] = append(hypercubes[primary], hc)
+		elr.Hypercubes = append(elr.Hypercubes, hc)
+	}
+	if len(elr.Hypercubes) == 0 {
+		return elr
+	}
+
+	// ディメンションのドメインに属する要素は基本項目から除く
+	domainMembers := make(map[*model.XMLElement]bool)
+	var markDomain func(elem *model.XMLElement)
+	markDomain = func(elem *model.XMLElement) {
+		if domainMembers[elem] {
+			return
+		}
+		domainMembers[elem] = true
+		for _, rel := range network.children(role, elem, ArcRoleDomainMember) {
+			markDomain(rel.To.(*model.XMLElement))
+		}
+	}
+	for i := range relations {
+		if relations[i].Arc.(*model.DefinitionArc).ArcRole == ArcRoleDimensionDomain {
+			markDomain(relations[i].To.(*model.XMLElement))
+		}
+	}
+
+	// 基本項目の domain-member 階層の根を探す
+	targets := make(map[*model.XMLElement]bool)
+	var candidates []*model.XMLElement
+	seen := make(map[*model.XMLElement]bool)
+	for i := range relations {
+		arc := relations[i].Arc.(*model.DefinitionArc)
+		from := relations[i].From.(*model.XMLElement)
+		if domainMembers[from] {
+			continue
+		}
+		switch arc.ArcRole {
+		case ArcRoleDomainMember:
+			targets[relations[i].To.(*model.XMLElement)] = true
+		case ArcRoleAll, ArcRoleNotAll:
+		default:
+			continue
+		}
+		if !seen[from] {
+			seen[from] = true
+			candidates = append(candidates, from)
+		}
+	}
+
+	// 基本項目は上位の項目のハイパーキューブを継承する
+	var walk func(elem *model.XMLElement, currentRole string, depth int, inherited []*Hypercube, visited map[*model.XMLElement]bool)
+	walk = func(elem *model.XMLElement, currentRole string, depth int, inherited []*Hypercube, visited map[*model.XMLElement]bool) {
+		if visited[elem] {
+			return
+		}
+		visited[elem] = true
+		defer delete(visited, elem)
+
+		applied := append(append([]*Hypercube{}, inherited...), hypercubes[elem]...)
+		elr.PrimaryItems = append(elr.PrimaryItems, &PrimaryItem{Element: elem, Depth: depth, Hypercubes: applied})
+		for _, rel := range network.children(currentRole, elem, ArcRoleDomainMember) {
+			walk(rel.To.(*model.XMLElement), nextRole(currentRole, rel), depth+1, applied, visited)
+		}
+	}
+	for _, root := range candidates {
+		if !targets[root] {
+			walk(root, role, 0, nil, map[*model.XMLElement]bool{})
+		}
+	}
+	return elr
+}
+
+func buildDimension(role string, rel *ArcRelation, network definitionNetwork, defaults map[*model.XMLElement]*model.XMLElement, typedDomains map[string]*model.XMLElement) *Dimension {
+	elem := rel.To.(*model.XMLElement)
+	dim := &Dimension{
+		Element: elem,
+		Role:    role,
+		Default: defaults[elem],
+	}
+	if elem.TypedDomainRef != "" {
+		dim.TypedDomain = typedDomains[ResolveTypedDomainRef(elem)]
+		return dim
+	}
+	for _, dd := range network.children(role, elem, ArcRoleDimensionDomain) {
+		dim.Domains = append(dim.Domains, buildDomainMember(nextRole(role, dd), dd, network, map[*model.XMLElement]bool{}))
+	}
+	return dim
+}
+
+func buildDomainMember(role string, rel *ArcRelation, network definitionNetwork, visited map[*model.XMLElement]bool) *DomainMember {
+	elem := rel.To.(*model.XMLElement)
+	member := &DomainMember{
+		Element: elem,
+		Role:    role,
+		Usable:  rel.Arc.(*model.DefinitionArc).Usable != "false" && rel.Arc.(*model.DefinitionArc).Usable != "0",
+	}
+	if visited[elem] {
+		return member
+	}
+	visited[elem] = true
+	defer delete(visited, elem)
+
+	for _, dm := range network.children(role, elem, ArcRoleDomainMember) {
+		member.Children = append(member.Children, buildDomainMember(nextRole(role, dm), dm, network, visited))
+	}
+	return member
+}
+
+// typedDomainRef をDTS内の要素のキー（スキーマのパス#id）に解決する
+func ResolveTypedDomainRef(elem *model.XMLElement) string {
+	ref := elem.TypedDomainRef
+	if len(ref) > 0 && ref[0] == '#' {
+		return elem.Schema.Path + ref
+	}
+	return parser.ResolveHref(elem.Schema.Path, ref)
+}
+
+// 要素に適用されるハイパーキューブを、全ELRから集める
+func (m *DimensionalModel) HypercubesFor(elem *model.XMLElement) []*Hypercube {
+	var result []*Hypercube
+	for _, elr := range m.ELRs {
+		for _, item := range elr.PrimaryItems {
+			if item.Element == elem {
+				result = append(result, item.Hypercubes...)
+			}
+		}
+	}
+	return result
+}
+
+// ドメインのメンバーを深さ優先で平坦化する
+func (d *Dimension) Members() []*DomainMember {
+	var result []*DomainMember
+	var walk func(members []*DomainMember)
+	walk = func(members []*DomainMember) {
+		for _, member := range members {
+			result = append(result, member)
+			walk(member.Children)
+		}
+	}
+	walk(d.Domains)
+	return result
+}
+
+// メンバーがドメインに含まれるか。含まれる場合は使用可能かも返す
+func (d *Dimension) FindMember(elem *model.XMLElement) (found bool, usable bool) {
+	for _, member := range d.Members() {
+		if member.Element == elem {
+			found = true
+			if member.Usable {
+				return true, true
+			}
+		}
+	}
+	return found, false
+}
@@ -0,0 +1,94 @@
+package resolver
+
+import (
+	"strings"
+	"thermal/model"
+)
+
+// インスタンスの脚注リンクを解決する
+// From はファクト、To は脚注（又は fact-explanatoryFact 等の場合はファクト）
+func TraverseFootnoteLink(instance *model.XBRLInstance) (map[string][]ArcRelation, error) {
+	factsByID := make(map[string]*model.Fact)
+	for i := range instance.Facts {
+		if instance.Facts[i].ID != "" {
+			factsByID[instance.Facts[i].ID] = &instance.Facts[i]
+		}
+	}
+
+	var relations []ArcRelation
+	for i := range instance.FootnoteLink {
+		link := &instance.FootnoteLink[i]
+		locMap := makeLocsMap(&link.Locs)
+		// 同じ xlink:label を持つ脚注が複数あれば、アークはそのすべてを指す
+		footnoteMap := make(map[string][]*model.Footnote, len(link.Footnotes))
+		for j := range link.Footnotes {
+			footnoteMap[link.Footnotes[j].Label] = append(footnoteMap[link.Footnotes[j].Label], &link.Footnotes[j])
+		}
+
+		// ロケータの指すファクトを取得する（ロケータ又はファクトが無ければ nil）
+		locatedFact := func(label string) (*model.Fact, error) {
+			loc, ok := locMap[label]
+			if !ok {
+				return nil, nil
+			}
+			id := loc.Href[strings.LastIndex(loc.Href, "#")+1:]
+			fact, ok := factsByID[id]
+			if !ok {
+				return nil, brokenLink(model.DiagInvalidLoc, instance.Path, label, "Loc invalid: %s", loc.Href)
+			}
+			return fact, nil
+		}
+
+		for j, arc := range link.Arcs {
+			from, err := locatedFact(arc.From)
+			if err != nil {
+				return nil, err
+			}
+			if from == nil {
+				if _, ok := locMap[arc.From]; !ok {
+					if err := brokenLink(model.DiagInvalidArc, instance.Path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+
+			var targets []any
+			if footnotes, ok := footnoteMap[arc.To]; ok {
+				for _, footnote := range footnotes {
+					targets = append(targets, footnote)
+				}
+			} else {
+				fact, err := locatedFact(arc.To)
+				if err != nil {
+					return nil, err
+				}
+				if fact == nil {
+					if _, ok := locMap[arc.To]; !ok {
+						if err := brokenLink(model.DiagInvalidArc, instance.Path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+					}
+					continue
+				}
+				targets = append(targets, fact)
+			}
+
+			for _, to := range targets {
+				var r ArcRelation
+				r.ArcRole = link.Role
+				r.Arc = &link.Arcs[j]
+				r.From = from
+				r.To = to
+				relations = append(relations, r)
+			}
+		}
+	}
+	relations = effectiveRelations(relations)
+
+	grouped := make(map[string][]ArcRelation)
+	for _, r := range relations {
+		grouped[r.ArcRole] = append(grouped[r.ArcRole], r)
+	}
+	return grouped, nil
+}
@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"encoding/xml"
+	"slices"
+	"sort"
+	"strings"
+	"thermal/model"
+)
+
+const StandardLabelRole = "http://www.xbrl.org/2003/role/label"
+
+// ラベルの言語の既定の優先順
+var DefaultLabelLangs = []string{"ja", "en"}
+
+// 短縮名のラベルロールをURIに展開する（例: verbose → http://www.xbrl.org/2003/role/verboseLabel）
+func ExpandLabelRole(role string) string {
+	if strings.Contains(role, "://") {
+		return role
+	}
+	switch role {
+	case "", "label", "standard":
+		return StandardLabelRole
+	}
+	if !strings.HasSuffix(role, "Label") {
+		role += "Label"
+	}
+	return "http://www.xbrl.org/2003/role/" + role
+}
+
+// 🔖 要素のラベルを引くためのリゾルバ
+type LabelResolver struct {
+	labels   map[*model.XMLElement][]*model.LabelLabel
+	elements map[xml.Name]*model.XMLElement
+}
+
+// DTSの名称リンクから、禁止・上書きを反映したラベルの一覧を作る
+func NewLabelResolver(schema *model.XBRLSchema) (*LabelResolver, error) {
+	r := &LabelResolver{
+		labels:   make(map[*model.XMLElement][]*model.LabelLabel),
+		elements: make(map[xml.Name]*model.XMLElement),
+	}
+	if schema == nil {
+		return r, nil
+	}
+	CollectElementsByName(schema, r.elements)
+
+	grouped, err := TraverseLabelLink(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// 禁止・上書きは関係の解決時に反映済み
+	linkRoles := make([]string, 0, len(grouped))
+	for linkRole := range grouped {
+		linkRoles = append(linkRoles, linkRole)
+	}
+	sort.Strings(linkRoles)
+	for _, linkRole := range linkRoles {
+		for _, relation := range grouped[linkRole] {
+			elem := relation.From.(*model.XMLElement)
+			r.labels[elem] = append(r.labels[elem], relation.To.(*model.LabelLabel))
+		}
+	}
+	return r, nil
+}
+
+// 要素の有効なラベルをすべて返す
+func (r *LabelResolver) Labels(elem *model.XMLElement) []*model.LabelLabel {
+	return r.labels[elem]
+}
+
+// 要素のラベルを選ぶ。指定言語で指定ロール → 標準ラベルの順に探し、無ければ ja → en の順に他の言語を探す
+func (r *LabelResolver) Label(elem *model.XMLElement, role string, langs ...string) string {
+	return SelectLabel(r.labels[elem], role, langs...)
+}
+
+// 名前空間付きの要素名からラベルを選ぶ
+func (r *LabelResolver) LabelByName(name xml.Name, role string, langs ...string) string {
+	elem, ok := r.elements[name]
+	if !ok {
+		return ""
+	}
+	return r.Label(elem, role, langs...)
+}
+
+// ラベルが無い場合は要素名を返す
+func (r *LabelResolver) LabelOrName(elem *model.XMLElement, role string, langs ...string) string {
+	if label := r.Label(elem, role, langs...); label != "" {
+		return label
+	}
+	return elem.Name
+}
+
+// ラベルの一覧から、ロールと言語の優先順に従って1件選ぶ
+func SelectLabel(labels []*model.LabelLabel, role string, langs ...string) string {
+	role = ExpandLabelRole(role)
+	roles := []string{role}
+	if role != StandardLabelRole {
+		roles = append(roles, StandardLabelRole)
+	}
+
+	candidates := append([]string{}, langs...)
+	for _, lang := range DefaultLabelLangs {
+		if !slices.Contains(candidates, lang) {
+			candidates = append(candidates, lang)
+		}
+	}
+
+	// 言語を優先し、同じ言語の中でロールをフォールバックする
+	for _, lang := range candidates {
+		for _, r := range roles {
+			for _, label := range labels {
+				if label.Role == r && matchLang(label.Lang, lang) {
+					return strings.TrimSpace(label.Value)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// xml:lang は大文字小文字を区別せず、ja と ja-JP のような地域付きも一致とする
+func matchLang(labelLang, lang string) bool {
+	labelLang = strings.ToLower(labelLang)
+	lang = strings.ToLower(lang)
+	return labelLang == lang || strings.HasPrefix(labelLang, lang+"-")
+}
+
+// 表示名を返す。リゾルバが無いとき（ラベル表示が無効のとき）は要素名
+func (r *LabelResolver) DisplayName(elem *model.XMLElement, role string, langs ...string) string {
+	if r == nil {
+		return elem.Name
+	}
+	return r.LabelOrName(elem, role, langs...)
+}
@@ -0,0 +1,144 @@
+package resolver
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"thermal/model"
+)
+
+// 等価な関係を判定するためのキー
+// 同じ拡張リンクロール・アーク種別・From/To で、use と priority 以外の属性が等しいものを等価とする（XBRL 2.1 3.5.3.9.7.4）
+type relationshipKey struct {
+	linkRole string
+	arcKind  string
+	from     any
+	to       any
+	attrs    string
+}
+
+// 等価な関係のまとまり
+type relationshipGroup struct {
+	priority   int
+	prohibited bool
+	effective  *ArcRelation
+}
+
+// 関係集合の規則を適用し、有効な関係だけを返す
+// 等価な関係は1つにまとめ、最も高い優先度のものを採用する。同じ優先度に禁止があれば関係自体を取り除く
+func effectiveRelations(relations []ArcRelation) []ArcRelation {
+	groups := make(map[relationshipKey]*relationshipGroup)
+	var keys []relationshipKey
+
+	for i := range relations {
+		rel := &relations[i]
+		base, kind, attrs := arcAttributes(rel.Arc)
+		key := relationshipKey{rel.ArcRole, kind, rel.From, rel.To, attrs}
+		prohibited := base.Use == "prohibited"
+
+		g, ok := groups[key]
+		if !ok {
+			g = &relationshipGroup{priority: base.Priority, prohibited: prohibited}
+			if !prohibited {
+				g.effective = rel
+			}
+			groups[key] = g
+			keys = append(keys, key)
+			continue
+		}
+
+		switch {
+		case base.Priority > g.priority:
+			// より高い優先度の関係で置き換える
+			g.priority = base.Priority
+			g.prohibited = prohibited
+			g.effective = nil
+			if !prohibited {
+				g.effective = rel
+			}
+		case base.Priority == g.priority:
+			if prohibited {
+				g.prohibited = true
+			} else if g.effective == nil {
+				g.effective = rel
+			}
+		}
+	}
+
+	// 元の出現順を保つ
+	result := make([]ArcRelation, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		if g.prohibited || g.effective == nil {
+			continue
+		}
+		result = append(result, *g.effective)
+	}
+	return result
+}
+
+// アークの共通属性と、等価判定に使う種別・属性を取り出す
+func arcAttributes(arc any) (model.ArcBase, string, string) {
+	switch a := arc.(type) {
+	case *model.LabelArc:
+		return a.ArcBase, "labelArc", ""
+	case *model.ReferenceArc:
+		return a.ArcBase, "referenceArc", ""
+	case *model.PresentationArc:
+		return a.ArcBase, "presentationArc", normalizeOrder(a.Order) + "|" + a.PreferredLabel
+	case *model.DefinitionArc:
+		return a.ArcBase, "definitionArc", strings.Join([]string{a.ArcRole, normalizeOrder(a.Order), a.TargetRole, a.Closed, a.ContextElement, a.Usable}, "|")
+	case *model.CalculationArc:
+		return a.ArcBase, "calculationArc", a.ArcRole + "|" + normalizeOrder(a.Order) + "|" + normalizeWeight(a.Weight)
+	case *model.GenericArc:
+		return a.ArcBase, "arc", ""
+	case *model.FootnoteArc:
+		return a.ArcBase, "footnoteArc", a.ArcRole + "|" + normalizeOrder(a.Order)
+	}
+	return model.ArcBase{}, "", ""
+}
+
+// order 属性は数値として比較する（省略時は 1）
+func normalizeOrder(order string) string {
+	if order == "" {
+		return "1"
+	}
+	f, err := strconv.ParseFloat(order, 64)
+	if err != nil {
+		return order
+	}
+	return formatFloat(f)
+}
+
+// アークの order 属性を数値で返す（省略時・不正な値は 1）
+func ArcOrder(rel *ArcRelation) float64 {
+	var order string
+	switch a := rel.Arc.(type) {
+	case *model.PresentationArc:
+		order = a.Order
+	case *model.DefinitionArc:
+		order = a.Order
+	case *model.CalculationArc:
+		order = a.Order
+	case *model.FootnoteArc:
+		order = a.Order
+	}
+	f, err := strconv.ParseFloat(order, 64)
+	if err != nil {
+		return 1
+	}
+	return f
+}
+
+// weight 属性は10進数の値として比較する
+func normalizeWeight(weight string) string {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(weight))
+	if !ok {
+		return weight
+	}
+	return r.RatString()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -14,11 +14,21 @@ type ArcRelation struct {
 }
 
 func TraverseLabelLink(schema *model.XBRLSchema) (map[string][]ArcRelation, error) {
-	return traverseLink(schema, dfsLabelLink)
+	labels := make(map[string]*model.LabelLabel)
+	CollectLabelsByHref(schema, labels)
+
+	return traverseLink(schema, func(s *model.XBRLSchema, elements map[string]*model.XMLElement, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
+		return dfsLabelLink(s, elements, labels, visited, relations)
+	})
 }
 
 func TraverseReferenceLink(schema *model.XBRLSchema) (map[string][]ArcRelation, error) {
-	return traverseLink(schema, dfsReferenceLink)
+	references := make(map[string]*model.ReferenceReference)
+	CollectReferencesByHref(schema, references)
+
+	return traverseLink(schema, func(s *model.XBRLSchema, elements map[string]*model.XMLElement, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
+		return dfsReferenceLink(s, elements, references, visited, relations)
+	})
 }
 
 func TraversePresentationLink(schema *model.XBRLSchema) (map[string][]ArcRelation, error) {
@@ -29,6 +39,10 @@ func TraverseDefinitionLink(schema *model.XBRLSchema) (map[string][]ArcRelation,
 	return traverseLink(schema, dfsDefinitionLink)
 }
 
+func TraverseCalculationLink(schema *model.XBRLSchema) (map[string][]ArcRelation, error) {
+	return traverseLink(schema, dfsCalculationLink)
+}
+
 func traverseLink(
 	schema *model.XBRLSchema,
 	traverseFn func(*model.XBRLSchema, map[string]*model.XMLElement, map[string]bool, []ArcRelation) ([]ArcRelation, error),
@@ -44,6 +58,7 @@ func traverseLink(
 	if err != nil {
 		return nil, err
 	}
+	relations = effectiveRelations(relations)
 
 	grouped := make(map[string][]ArcRelation)
 	for _, r := range relations {
@@ -100,7 +115,18 @@ func makeLocsMap(locs *[]model.Loc) map[string]*model.Loc {
 	return locMap
 }
 
-func dfsLabelLink(schema *model.XBRLSchema, elements map[string]*model.XMLElement, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
+// 壊れたアーク・ロケータを診断情報に記録する
+// 厳格モードではエラーを返して関係の解決を中断し、そうでなければ nil を返して読み飛ばす
+func brokenLink(code, file, element, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	parser.Report(model.SeverityError, code, file, element, "%v", err)
+	if parser.Strict() {
+		return err
+	}
+	return nil
+}
+
+func dfsLabelLink(schema *model.XBRLSchema, elements map[string]*model.XMLElement, labels map[string]*model.LabelLabel, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
 
 	return dfsLink(
 		schema, elements, visited,
@@ -126,37 +152,57 @@ func dfsLabelLink(schema *model.XBRLSchema, elements map[string]*model.XMLElemen
 			var rels []ArcRelation
 			for _, elr := range llb.LabelLinks {
 				locMap := makeLocsMap(&elr.Locs)
-				labelMap := make(map[string]*model.LabelLabel, len(elr.Labels))
-				for _, label := range elr.Labels {
-					labelMap[label.Label] = &label
+				// 同じ xlink:label を持つ名称が複数あれば、アークはそのすべてを指す
+				labelMap := make(map[string][]*model.LabelLabel, len(elr.Labels))
+				for j := range elr.Labels {
+					labelMap[elr.Labels[j].Label] = append(labelMap[elr.Labels[j].Label], &elr.Labels[j])
 				}
 				for i, arc := range elr.Arcs {
 					loc, ok := locMap[arc.From]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: from=%s", arc.From)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+							return nil, err
+						}
+						continue
 					}
-					label, ok := labelMap[arc.To]
+					targets, ok := labelMap[arc.To]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: to=%s", arc.To)
+						// 他の名称リンクベースの名称をロケータで指すアーク（禁止・上書き）
+						if locTo, isLoc := locMap[arc.To]; isLoc {
+							var label *model.LabelLabel
+							label, ok = labels[parser.ResolveHref(path, locTo.Href)]
+							targets = []*model.LabelLabel{label}
+						}
+					}
+					if !ok {
+						if err := brokenLink(model.DiagInvalidArc, path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key := parser.ResolveHref(path, loc.Href)
 					elem, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, loc.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
+					}
+					for _, label := range targets {
+						var r ArcRelation
+						r.ArcRole = elr.Role
+						r.Arc = &elr.Arcs[i]
+						r.From = elem
+						r.To = label
+						rels = append(rels, r)
 					}
-					var r ArcRelation
-					r.ArcRole = elr.Role
-					r.Arc = &elr.Arcs[i]
-					r.From = elem
-					r.To = label
-					rels = append(rels, r)
 				}
 			}
 			return rels, nil
 		}, relations)
 }
 
-func dfsReferenceLink(schema *model.XBRLSchema, elements map[string]*model.XMLElement, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
+func dfsReferenceLink(schema *model.XBRLSchema, elements map[string]*model.XMLElement, references map[string]*model.ReferenceReference, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
 
 	return dfsLink(
 		schema, elements, visited,
@@ -182,30 +228,50 @@ func dfsReferenceLink(schema *model.XBRLSchema, elements map[string]*model.XMLEl
 
 			for _, elr := range rlb.ReferenceLinks {
 				locMap := makeLocsMap(&elr.Locs)
-				rerefenceMap := make(map[string]*model.ReferenceReference, len(elr.References))
-				for _, reference := range elr.References {
-					rerefenceMap[reference.Label] = &reference
+				// 同じ xlink:label を持つ参照が複数あれば、アークはそのすべてを指す
+				referenceMap := make(map[string][]*model.ReferenceReference, len(elr.References))
+				for j := range elr.References {
+					referenceMap[elr.References[j].Label] = append(referenceMap[elr.References[j].Label], &elr.References[j])
 				}
 				for i, arc := range elr.Arcs {
 					loc, ok := locMap[arc.From]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: from=%s", arc.From)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+							return nil, err
+						}
+						continue
 					}
-					ref, ok := rerefenceMap[arc.To]
+					targets, ok := referenceMap[arc.To]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: to=%s", arc.To)
+						// 他の参照リンクベースの参照をロケータで指すアーク（禁止・上書き）
+						if locTo, isLoc := locMap[arc.To]; isLoc {
+							var ref *model.ReferenceReference
+							ref, ok = references[parser.ResolveHref(path, locTo.Href)]
+							targets = []*model.ReferenceReference{ref}
+						}
+					}
+					if !ok {
+						if err := brokenLink(model.DiagInvalidArc, path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key := parser.ResolveHref(path, loc.Href)
 					elem, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, loc.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
+					}
+					for _, ref := range targets {
+						var r ArcRelation
+						r.ArcRole = elr.Role
+						r.Arc = &elr.Arcs[i]
+						r.From = elem
+						r.To = ref
+						rels = append(rels, r)
 					}
-					var r ArcRelation
-					r.ArcRole = elr.Role
-					r.Arc = &elr.Arcs[i]
-					r.From = elem
-					r.To = ref
-					rels = append(rels, r)
 				}
 			}
 			return rels, nil
@@ -241,22 +307,34 @@ func dfsPresentationLink(schema *model.XBRLSchema, elements map[string]*model.XM
 				for i, arc := range elr.Arcs {
 					locFrom, ok := locMap[arc.From]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: from=%s", arc.From)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key := parser.ResolveHref(plb.Path, locFrom.Href)
 					elemFrom, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, locFrom.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
 					}
 
 					locTo, ok := locMap[arc.To]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: to=%s", arc.To)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key = parser.ResolveHref(path, locTo.Href)
 					elemTo, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, locTo.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
 					}
 
 					var r ArcRelation
@@ -300,22 +378,105 @@ func dfsDefinitionLink(schema *model.XBRLSchema, elements map[string]*model.XMLE
 				for i, arc := range elr.Arcs {
 					locFrom, ok := locMap[arc.From]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: from=%s", arc.From)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key := parser.ResolveHref(dlb.Path, locFrom.Href)
 					elemFrom, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, locFrom.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
 					}
 
 					locTo, ok := locMap[arc.To]
 					if !ok {
-						return nil, fmt.Errorf("Arc invalid: to=%s", arc.To)
+						if err := brokenLink(model.DiagInvalidArc, path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					key = parser.ResolveHref(path, locTo.Href)
 					elemTo, ok := elements[key]
 					if !ok {
-						return nil, fmt.Errorf("Loc invalid: %s", key)
+						if err := brokenLink(model.DiagInvalidLoc, path, locTo.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
+					}
+
+					var r ArcRelation
+					r.ArcRole = elr.Role
+					r.Arc = &elr.Arcs[i]
+					r.From = elemFrom
+					r.To = elemTo
+					rels = append(rels, r)
+				}
+			}
+			return rels, nil
+		}, relations)
+}
+
+func dfsCalculationLink(schema *model.XBRLSchema, elements map[string]*model.XMLElement, visited map[string]bool, relations []ArcRelation) ([]ArcRelation, error) {
+	return dfsLink(
+		schema, elements, visited,
+		func(s *model.XBRLSchema) []string {
+			linkbases := make([]string, len(s.ReferencedCalculationLinkbases))
+			for i, v := range s.ReferencedCalculationLinkbases {
+				linkbases[i] = v.Path
+			}
+			return linkbases
+		},
+		func(s *model.XBRLSchema, path string) ([]ArcRelation, error) {
+			var rels []ArcRelation
+			var clb *model.CalculationLinkBase
+			for i := range s.ReferencedCalculationLinkbases {
+				if s.ReferencedCalculationLinkbases[i].Path == path {
+					clb = s.ReferencedCalculationLinkbases[i]
+					break
+				}
+			}
+			if clb == nil {
+				return nil, fmt.Errorf("Linkbase not found.")
+			}
+
+			for _, elr := range clb.CalculationLinks {
+				locMap := makeLocsMap(&elr.Locs)
+
+				for i, arc := range elr.Arcs {
+					locFrom, ok := locMap[arc.From]
+					if !ok {
+						if err := brokenLink(model.DiagInvalidArc, path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+							return nil, err
+						}
+						continue
+					}
+					key := parser.ResolveHref(clb.Path, locFrom.Href)
+					elemFrom, ok := elements[key]
+					if !ok {
+						if err := brokenLink(model.DiagInvalidLoc, path, locFrom.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
+					}
+
+					locTo, ok := locMap[arc.To]
+					if !ok {
+						if err := brokenLink(model.DiagInvalidArc, path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+							return nil, err
+						}
+						continue
+					}
+					key = parser.ResolveHref(path, locTo.Href)
+					elemTo, ok := elements[key]
+					if !ok {
+						if err := brokenLink(model.DiagInvalidLoc, path, locTo.Label, "Loc invalid: %s", key); err != nil {
+							return nil, err
+						}
+						continue
 					}
 
 					var r ArcRelation
@@ -339,6 +500,7 @@ func TraverseGenericLink(schema *model.XBRLSchema, roleTypes map[string]*model.R
 	if err != nil {
 		return nil, err
 	}
+	relations = effectiveRelations(relations)
 
 	grouped := make(map[string][]ArcRelation)
 	for _, r := range relations {
@@ -364,30 +526,41 @@ func dfsGenericLink(schema *model.XBRLSchema, roleTypes map[string]*model.RoleTy
 
 		for _, elr := range linkbase.GenericLinks {
 			locMap := makeLocsMap(&elr.Locs)
-			labelMap := make(map[string]*model.GenericLabel, len(elr.Labels))
-			for _, label := range elr.Labels {
-				labelMap[label.Label] = &label
+			labelMap := make(map[string][]*model.GenericLabel, len(elr.Labels))
+			for j := range elr.Labels {
+				labelMap[elr.Labels[j].Label] = append(labelMap[elr.Labels[j].Label], &elr.Labels[j])
 			}
 			for i, arc := range elr.Arcs {
 				loc, ok := locMap[arc.From]
 				if !ok {
-					return nil, fmt.Errorf("Arc invalid: from=%s", arc.From)
+					if err := brokenLink(model.DiagInvalidArc, linkbase.Path, arc.From, "Arc invalid: from=%s", arc.From); err != nil {
+						return nil, err
+					}
+					continue
 				}
-				label, ok := labelMap[arc.To]
+				targets, ok := labelMap[arc.To]
 				if !ok {
-					return nil, fmt.Errorf("Arc invalid: to=%s", arc.To)
+					if err := brokenLink(model.DiagInvalidArc, linkbase.Path, arc.To, "Arc invalid: to=%s", arc.To); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				key := parser.ResolveHref(linkbase.Path, loc.Href)
 				roleType, ok := roleTypes[key]
 				if !ok {
-					return nil, fmt.Errorf("Loc invalid: %s", key)
+					if err := brokenLink(model.DiagInvalidLoc, linkbase.Path, loc.Label, "Loc invalid: %s", key); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				for _, label := range targets {
+					var r ArcRelation
+					r.ArcRole = elr.Role
+					r.Arc = &elr.Arcs[i]
+					r.From = roleType
+					r.To = label
+					relations = append(relations, r)
 				}
-				var r ArcRelation
-				r.ArcRole = elr.Role
-				r.Arc = &elr.Arcs[i]
-				r.From = roleType
-				r.To = label
-				relations = append(relations, r)
 			}
 		}
 	}
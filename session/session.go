@@ -1,8 +1,11 @@
 package session
 
 import (
+	"fmt"
 	"io"
 	"thermal/model"
+	"thermal/output"
+	"thermal/resolver"
 )
 
 type Session struct {
@@ -12,4 +15,51 @@ type Session struct {
 	Stdin    io.Reader
 	Stdout   io.Writer
 	Stderr   io.Writer
+	Format   string // 出力形式の既定値（空ならコマンドごとの既定）
+
+	labels       *resolver.LabelResolver
+	labelsSchema *model.XBRLSchema
+}
+
+// ラベルリゾルバを返す。DTSごとに1度だけ作成してキャッシュする
+func (s *Session) Labels() (*resolver.LabelResolver, error) {
+	if s.labels != nil && s.labelsSchema == s.Schema {
+		return s.labels, nil
+	}
+	labels, err := resolver.NewLabelResolver(s.Schema)
+	if err != nil {
+		return nil, err
+	}
+	s.labels = labels
+	s.labelsSchema = s.Schema
+	return labels, nil
+}
+
+// 出力形式を決める。コマンドの -o、セッションの既定、コマンドの既定の順に優先する
+func (s *Session) OutputFormat(format, fallback string) string {
+	if format != "" {
+		return format
+	}
+	if s.Format != "" {
+		return s.Format
+	}
+	return fallback
+}
+
+// 値を出力形式に従って書き出す（既定は YAML）
+func (s *Session) Write(format string, v any) error {
+	format = s.OutputFormat(format, output.YAML)
+	if err := output.Write(s.Stdout, format, v); err != nil {
+		return fmt.Errorf("%s encode error: %v", format, err)
+	}
+	return nil
+}
+
+// ヘッダーと行を出力形式に従って書き出す（既定は表）
+func (s *Session) WriteRows(format string, header []string, rows [][]string) error {
+	format = s.OutputFormat(format, output.Table)
+	if err := output.WriteRows(s.Stdout, format, header, rows); err != nil {
+		return fmt.Errorf("%s encode error: %v", format, err)
+	}
+	return nil
 }
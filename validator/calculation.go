@@ -0,0 +1,410 @@
+package validator
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"thermal/model"
+	"thermal/parser"
+	"thermal/resolver"
+	"time"
+)
+
+// 計算の一貫性チェックのルール
+const (
+	CalcModeXBRL21 = "2.1" // XBRL 2.1 (5.2.5.2)
+	CalcModeCalc11 = "1.1" // Calculations 1.1 (round-to-nearest)
+)
+
+// summation-item のアークロール。Calculations 1.1 では 2023 のアークロールも同じ規則で扱う
+const (
+	summationItemArcRole   = "http://www.xbrl.org/2003/arcrole/summation-item"
+	summationItemArcRole11 = "https://xbrl.org/2023/arcrole/summation-item"
+)
+
+func isSummationItem(arcRole, mode string) bool {
+	return arcRole == summationItemArcRole || (mode == CalcModeCalc11 && arcRole == summationItemArcRole11)
+}
+
+// 計算不一致1件分
+type CalcInconsistency struct {
+	Role         string
+	Total        *model.Fact
+	TotalValue   string
+	ComputedSum  string
+	Difference   string
+	Decimals     string
+	Contributors []CalcContributor
+}
+
+// 合計に寄与したファクト
+type CalcContributor struct {
+	Fact   *model.Fact
+	Weight float64
+	weight *big.Rat // 計算に用いる重み（weight 属性の字句から作る）
+}
+
+// 子要素と重み
+type calcItem struct {
+	concept string
+	weight  *big.Rat
+}
+
+// 計算リンクベースに従って、インスタンスのファクトが合計と一致するか検証する
+func CheckCalculations(schema *model.XBRLSchema, instance *model.XBRLInstance, mode string, rtPattern string) ([]CalcInconsistency, error) {
+	if mode != CalcModeXBRL21 && mode != CalcModeCalc11 {
+		return nil, fmt.Errorf("unknown calculation mode: %s", mode)
+	}
+
+	grouped, err := resolver.TraverseCalculationLink(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// ファクトを (要素, コンテキスト, ユニット) ごとにまとめる
+	// コンテキストと単位は ID ではなく内容で比較する（c-equal, u-equal）
+	keys := newFactKeys(instance)
+	factsByKey := make(map[string][]*model.Fact)
+	for i := range instance.Facts {
+		fact := &instance.Facts[i]
+		if fact.Nil == "true" || fact.UnitRef == "" || fact.TransformError != "" {
+			continue
+		}
+		key := keys.key(conceptName(fact.XMLName.Space, fact.XMLName.Local), fact.ContextRef, fact.UnitRef)
+		factsByKey[key] = append(factsByKey[key], fact)
+	}
+
+	roles := make([]string, 0, len(grouped))
+	for role := range grouped {
+		if rtPattern == "" || parser.WildcardMatch(rtPattern, role) {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+
+	var result []CalcInconsistency
+	for _, role := range roles {
+		// 合計要素ごとに子要素をまとめる
+		items := make(map[string][]calcItem)
+		for _, rel := range grouped[role] {
+			arc := rel.Arc.(*model.CalculationArc)
+			if !isSummationItem(arc.ArcRole, mode) {
+				continue
+			}
+			weight, ok := new(big.Rat).SetString(strings.TrimSpace(arc.Weight))
+			if !ok {
+				return nil, fmt.Errorf("invalid weight: %s", arc.Weight)
+			}
+			from := rel.From.(*model.XMLElement)
+			to := rel.To.(*model.XMLElement)
+			parent := conceptName(from.Schema.TargetNS, from.Name)
+			items[parent] = append(items[parent], calcItem{
+				concept: conceptName(to.Schema.TargetNS, to.Name),
+				weight:  weight,
+			})
+		}
+
+		// 合計要素のファクトはインスタンスの出現順に処理する
+		for i := range instance.Facts {
+			total := &instance.Facts[i]
+			parent := conceptName(total.XMLName.Space, total.XMLName.Local)
+			children, ok := items[parent]
+			if !ok || total.Nil == "true" || total.UnitRef == "" || total.TransformError != "" {
+				continue
+			}
+			totals := factsByKey[keys.key(parent, total.ContextRef, total.UnitRef)]
+			if len(totals) == 0 || totals[0] != total {
+				// 重複ファクトは先頭の1件でのみ評価する
+				continue
+			}
+
+			inc, err := checkBinding(role, totals, children, factsByKey, keys, total.ContextRef, total.UnitRef, mode)
+			if err != nil {
+				return nil, err
+			}
+			if inc != nil {
+				result = append(result, *inc)
+			}
+		}
+	}
+	return result, nil
+}
+
+// 1つの合計ファクトと寄与ファクトの組み合わせを検証する
+func checkBinding(role string, totals []*model.Fact, children []calcItem, factsByKey map[string][]*model.Fact, keys *factKeys, contextRef, unitRef, mode string) (*CalcInconsistency, error) {
+	total, ok, err := pickFact(totals, mode)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var contributors []CalcContributor
+	for _, child := range children {
+		facts := factsByKey[keys.key(child.concept, contextRef, unitRef)]
+		if len(facts) == 0 {
+			continue
+		}
+		fact, ok, err := pickFact(facts, mode)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// 不整合な重複があるときはこの組み合わせを評価しない
+			return nil, nil
+		}
+		weight, _ := child.weight.Float64()
+		contributors = append(contributors, CalcContributor{Fact: fact, Weight: weight, weight: child.weight})
+	}
+	if len(contributors) == 0 {
+		return nil, nil
+	}
+
+	totalValue, totalDec, err := parseNumericFact(total)
+	if err != nil {
+		return nil, err
+	}
+
+	// 比較に用いる小数点以下桁数
+	dec := totalDec
+	if mode == CalcModeCalc11 {
+		for _, c := range contributors {
+			d, err := parseDecimals(c.Fact.Decimals)
+			if err != nil {
+				return nil, err
+			}
+			dec = min(dec, d)
+		}
+	}
+
+	sum := new(big.Rat)
+	for _, c := range contributors {
+		value, d, err := parseNumericFact(c.Fact)
+		if err != nil {
+			return nil, err
+		}
+		if mode == CalcModeXBRL21 {
+			value = roundDecimals(value, d)
+		} else {
+			value = roundDecimals(value, dec)
+		}
+		sum.Add(sum, new(big.Rat).Mul(value, c.weight))
+	}
+
+	roundedTotal := roundDecimals(totalValue, dec)
+	roundedSum := roundDecimals(sum, dec)
+	if roundedTotal.Cmp(roundedSum) == 0 {
+		return nil, nil
+	}
+
+	return &CalcInconsistency{
+		Role:         role,
+		Total:        total,
+		TotalValue:   FormatRat(totalValue),
+		ComputedSum:  FormatRat(sum),
+		Difference:   FormatRat(new(big.Rat).Sub(totalValue, sum)),
+		Decimals:     total.Decimals,
+		Contributors: contributors,
+	}, nil
+}
+
+// 重複ファクトから評価に用いる1件を選ぶ
+// XBRL 2.1では重複があれば評価しない。Calculations 1.1では一貫した重複なら最も精度の高いものを使う。
+func pickFact(facts []*model.Fact, mode string) (*model.Fact, bool, error) {
+	if len(facts) == 1 {
+		return facts[0], true, nil
+	}
+	if mode == CalcModeXBRL21 {
+		return nil, false, nil
+	}
+
+	// 最も精度の低い桁数に丸めて、全て一致するか確認する
+	minDec := infDecimals
+	best := facts[0]
+	bestDec := -infDecimals
+	for _, f := range facts {
+		d, err := parseDecimals(f.Decimals)
+		if err != nil {
+			return nil, false, err
+		}
+		minDec = min(minDec, d)
+		if d > bestDec {
+			best, bestDec = f, d
+		}
+	}
+	var first *big.Rat
+	for _, f := range facts {
+		v, _, err := parseNumericFact(f)
+		if err != nil {
+			return nil, false, err
+		}
+		v = roundDecimals(v, minDec)
+		if first == nil {
+			first = v
+		} else if first.Cmp(v) != 0 {
+			return nil, false, nil
+		}
+	}
+	return best, true, nil
+}
+
+// decimals属性が INF の場合の桁数
+const infDecimals = 1 << 16
+
+func parseDecimals(decimals string) (int, error) {
+	if decimals == "" || decimals == "INF" {
+		return infDecimals, nil
+	}
+	d, err := strconv.Atoi(decimals)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimals: %s", decimals)
+	}
+	return d, nil
+}
+
+func parseNumericFact(fact *model.Fact) (*big.Rat, int, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(fact.Value))
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid numeric value: %s=%s", fact.XMLName.Local, fact.Value)
+	}
+	d, err := parseDecimals(fact.Decimals)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d, nil
+}
+
+// 小数点以下 dec 桁に丸める（偶数丸め）
+func roundDecimals(value *big.Rat, dec int) *big.Rat {
+	if dec >= infDecimals {
+		return value
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(dec))), nil))
+	scaled := new(big.Rat).Set(value)
+	if dec >= 0 {
+		scaled.Mul(scaled, scale)
+	} else {
+		scaled.Quo(scaled, scale)
+	}
+
+	q, r := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	twice := new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2))
+	cmp := twice.Cmp(scaled.Denom())
+	if cmp > 0 || (cmp == 0 && q.Bit(0) == 1) {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	rounded := new(big.Rat).SetInt(q)
+	if dec >= 0 {
+		rounded.Quo(rounded, scale)
+	} else {
+		rounded.Mul(rounded, scale)
+	}
+	return rounded
+}
+
+// 有理数を末尾の0を除いた10進表記にする
+func FormatRat(r *big.Rat) string {
+	s := r.FloatString(16)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func conceptName(namespace, local string) string {
+	return fmt.Sprintf("{%s}%s", namespace, local)
+}
+
+// c-equal なコンテキスト・u-equal な単位が同じ文字列になるように正規化した表現
+type factKeys struct {
+	contexts map[string]string // コンテキストID → 正規化した表現
+	units    map[string]string // 単位ID → 正規形
+}
+
+func newFactKeys(instance *model.XBRLInstance) *factKeys {
+	k := &factKeys{
+		contexts: make(map[string]string, len(instance.Contexts)),
+		units:    make(map[string]string, len(instance.Units)),
+	}
+	for i := range instance.Contexts {
+		k.contexts[instance.Contexts[i].ID] = normalizeContext(&instance.Contexts[i], instance.Namespaces)
+	}
+	for i := range instance.Units {
+		k.units[instance.Units[i].ID] = instance.Units[i].Canonical()
+	}
+	return k
+}
+
+// 定義の無いコンテキスト・単位は ID のまま比較する
+func (k *factKeys) key(concept, contextRef, unitRef string) string {
+	context, ok := k.contexts[contextRef]
+	if !ok {
+		context = "#" + contextRef
+	}
+	unit, ok := k.units[unitRef]
+	if !ok {
+		unit = "#" + unitRef
+	}
+	return concept + "|" + context + "|" + unit
+}
+
+// 企業識別子・期間・ディメンションを正規化して並べる
+func normalizeContext(context *model.Context, nsMap map[string]string) string {
+	identifier := context.Entity.Identifier
+	parts := []string{
+		strings.TrimSpace(identifier.Scheme) + " " + strings.TrimSpace(identifier.Value),
+		normalizePeriod(context.Period),
+	}
+
+	var dims []string
+	for _, cd := range context.Dimensions() {
+		dim := parser.ResolveXMLName(cd.Dimension, nsMap)
+		member := cd.Member
+		if !cd.Typed {
+			m := parser.ResolveXMLName(cd.Member, nsMap)
+			member = conceptName(m.Space, m.Local)
+		}
+		dims = append(dims, cd.ContextElement+" "+conceptName(dim.Space, dim.Local)+"="+member)
+	}
+	sort.Strings(dims)
+	return strings.Join(append(parts, dims...), "|")
+}
+
+// 日付だけの期間を日時にそろえる。終了日・時点はその日の終わり（翌日 0 時）とする
+func normalizePeriod(period model.Period) string {
+	if instant := strings.TrimSpace(period.Instant); instant != "" {
+		return normalizeDateTime(instant, true)
+	}
+	start, end := strings.TrimSpace(period.StartDate), strings.TrimSpace(period.EndDate)
+	if start == "" && end == "" {
+		return "forever"
+	}
+	return normalizeDateTime(start, false) + "/" + normalizeDateTime(end, true)
+}
+
+func normalizeDateTime(value string, endOfDay bool) string {
+	if strings.Contains(value, "T") {
+		return value
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return value
+	}
+	if endOfDay {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date.Format("2006-01-02T15:04:05")
+}
@@ -0,0 +1,410 @@
+package validator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"thermal/model"
+	"thermal/parser"
+	"thermal/resolver"
+)
+
+// ディメンション検証のエラーコード（XDT 1.0 の xbrldie エラーに合わせる）
+const (
+	DimErrPrimaryItemInvalid   = "xbrldie:PrimaryItemDimensionallyInvalidError"
+	DimErrDefaultValueUsed     = "xbrldie:DefaultValueUsedInInstanceError"
+	DimErrUndefinedQName       = "xbrldie:ExplicitMemberUndefinedQNameError"
+	DimErrNotExplicitDimension = "xbrldie:ExplicitMemberNotExplicitDimensionError"
+	DimErrNotTypedDimension    = "xbrldie:TypedMemberNotTypedDimensionError"
+	DimErrIllegalTypedContent  = "xbrldie:IllegalTypedDimensionContentError"
+	DimErrRepeatedDimension    = "xbrldie:RepeatedDimensionInInstanceError"
+	DimErrMemberNotInDomain    = "MemberNotInDomain"
+)
+
+// ディメンション検証の指摘1件分
+// Fact が nil のものはコンテキスト自体の指摘
+type DimensionIssue struct {
+	Code      string
+	Message   string
+	Fact      *model.Fact
+	Context   string
+	Role      string
+	Hypercube string
+}
+
+// 名前空間を解決したコンテキストのディメンション
+type contextDimension struct {
+	dimension *model.XMLElement
+	member    *model.XMLElement // 明示的メンバー（型付きメンバーは nil）
+	container string            // segment 又は scenario
+}
+
+// 定義リンクのディメンション構造に従って、インスタンスのコンテキストとファクトを検証する
+func CheckDimensions(schema *model.XBRLSchema, instance *model.XBRLInstance) ([]DimensionIssue, error) {
+	dm, err := resolver.BuildDimensionalModel(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make(map[xml.Name]*model.XMLElement)
+	resolver.CollectElementsByName(schema, elements)
+	elementsByHref := make(map[string]*model.XMLElement)
+	resolver.CollectElementsByHref(schema, elementsByHref)
+
+	// DTSで定義されたディメンション（ハイパーキューブに含まれるもの）
+	dimensions := make(map[*model.XMLElement][]*resolver.Dimension)
+	for _, elr := range dm.ELRs {
+		for _, hc := range elr.Hypercubes {
+			for _, dim := range hc.Dimensions {
+				dimensions[dim.Element] = append(dimensions[dim.Element], dim)
+			}
+		}
+	}
+
+	var issues []DimensionIssue
+
+	// コンテキストごとのディメンションを解決し、コンテキスト単位の誤りを検出する
+	contexts := make(map[string][]contextDimension, len(instance.Contexts))
+	for i := range instance.Contexts {
+		context := &instance.Contexts[i]
+		dims, contextIssues := resolveContextDimensions(context, instance.Namespaces, elements, elementsByHref, dimensions, dm.Defaults)
+		contexts[context.ID] = dims
+		issues = append(issues, contextIssues...)
+	}
+
+	// ファクトごとに、基本項目のハイパーキューブを満たすか検証する
+	for i := range instance.Facts {
+		fact := &instance.Facts[i]
+		dims, ok := contexts[fact.ContextRef]
+		if !ok {
+			continue
+		}
+		elem, ok := elements[fact.XMLName]
+		if !ok {
+			continue
+		}
+		hypercubes := dm.HypercubesFor(elem)
+		if len(hypercubes) == 0 {
+			// どのELRでも基本項目でない要素はディメンションの制約を受けない
+			continue
+		}
+
+		// ELRごとに評価し、いずれかのELRで妥当であればよい
+		var roles []string
+		byRole := make(map[string][]*resolver.Hypercube)
+		for _, hc := range hypercubes {
+			if _, ok := byRole[hc.Role]; !ok {
+				roles = append(roles, hc.Role)
+			}
+			byRole[hc.Role] = append(byRole[hc.Role], hc)
+		}
+
+		var rejected []DimensionIssue
+		valid := false
+		for _, role := range roles {
+			hc, reason := evaluateELR(byRole[role], dims)
+			if hc == nil {
+				valid = true
+				break
+			}
+			rejected = append(rejected, DimensionIssue{
+				Code:      DimErrPrimaryItemInvalid,
+				Message:   reason,
+				Fact:      fact,
+				Context:   fact.ContextRef,
+				Role:      role,
+				Hypercube: hc.Element.Name,
+			})
+		}
+		if !valid {
+			issues = append(issues, rejected...)
+		}
+	}
+	return issues, nil
+}
+
+func resolveContextDimensions(
+	context *model.Context,
+	nsMap map[string]string,
+	elements map[xml.Name]*model.XMLElement,
+	elementsByHref map[string]*model.XMLElement,
+	dimensions map[*model.XMLElement][]*resolver.Dimension,
+	defaults map[*model.XMLElement]*model.XMLElement,
+) ([]contextDimension, []DimensionIssue) {
+
+	var dims []contextDimension
+	var issues []DimensionIssue
+	report := func(code, format string, args ...any) {
+		issues = append(issues, DimensionIssue{
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+			Context: context.ID,
+		})
+	}
+
+	seen := make(map[*model.XMLElement]bool)
+	for _, cd := range context.Dimensions() {
+		dimElem, ok := elements[parser.ResolveXMLName(cd.Dimension, nsMap)]
+		if !ok {
+			report(DimErrUndefinedQName, "dimension %s is not defined in the DTS", cd.Dimension)
+			continue
+		}
+		if seen[dimElem] {
+			report(DimErrRepeatedDimension, "dimension %s is used more than once", cd.Dimension)
+			continue
+		}
+		seen[dimElem] = true
+
+		d := contextDimension{dimension: dimElem, container: cd.ContextElement}
+		isTyped := dimElem.TypedDomainRef != ""
+
+		if cd.Typed {
+			if !isTyped {
+				report(DimErrNotTypedDimension, "dimension %s is not a typed dimension", cd.Dimension)
+				continue
+			}
+			if err := checkTypedMember(dimElem, cd.Member, nsMap, elementsByHref); err != nil {
+				report(DimErrIllegalTypedContent, "typed member of %s is invalid: %v", cd.Dimension, err)
+			}
+			dims = append(dims, d)
+			continue
+		}
+
+		if isTyped || len(dimensions[dimElem]) == 0 && !strings.HasSuffix(dimElem.SubstitutionGroup, ":dimensionItem") {
+			report(DimErrNotExplicitDimension, "dimension %s is not an explicit dimension", cd.Dimension)
+			continue
+		}
+		member, ok := elements[parser.ResolveXMLName(cd.Member, nsMap)]
+		if !ok {
+			report(DimErrUndefinedQName, "member %s of dimension %s is not defined in the DTS", cd.Member, cd.Dimension)
+			continue
+		}
+		d.member = member
+
+		if defaults[dimElem] == member {
+			report(DimErrDefaultValueUsed, "default member %s of dimension %s is used explicitly", cd.Member, cd.Dimension)
+		}
+
+		// いずれのハイパーキューブのドメインにも無いメンバー
+		inDomain := false
+		for _, dim := range dimensions[dimElem] {
+			if found, _ := dim.FindMember(member); found {
+				inDomain = true
+				break
+			}
+		}
+		if !inDomain {
+			report(DimErrMemberNotInDomain, "member %s is not in any domain of dimension %s", cd.Member, cd.Dimension)
+		}
+		dims = append(dims, d)
+	}
+	return dims, issues
+}
+
+// ELR内の全ハイパーキューブで評価する。妥当でなければ原因のハイパーキューブと理由を返す
+func evaluateELR(hypercubes []*resolver.Hypercube, dims []contextDimension) (*resolver.Hypercube, string) {
+	for _, hc := range hypercubes {
+		reason := satisfies(hc, dims)
+		if hc.ArcRole == resolver.ArcRoleNotAll {
+			if reason == "" {
+				return hc, fmt.Sprintf("context matches notAll hypercube %s", hc.Element.Name)
+			}
+			continue
+		}
+		if reason != "" {
+			return hc, reason
+		}
+	}
+	return nil, ""
+}
+
+// ハイパーキューブを満たせば空文字、満たさなければ理由を返す
+func satisfies(hc *resolver.Hypercube, dims []contextDimension) string {
+	var inContainer []contextDimension
+	for _, d := range dims {
+		if hc.ContextElement == "" || d.container == hc.ContextElement {
+			inContainer = append(inContainer, d)
+		}
+	}
+
+	hcDims := make(map[*model.XMLElement]bool, len(hc.Dimensions))
+	for _, dim := range hc.Dimensions {
+		hcDims[dim.Element] = true
+
+		var value *contextDimension
+		for i := range inContainer {
+			if inContainer[i].dimension == dim.Element {
+				value = &inContainer[i]
+				break
+			}
+		}
+
+		if value == nil {
+			if dim.Default == nil {
+				return fmt.Sprintf("dimension %s is required in %s but missing", dim.Element.Name, containerName(hc))
+			}
+			continue
+		}
+		if dim.Element.TypedDomainRef != "" {
+			continue
+		}
+		found, usable := dim.FindMember(value.member)
+		if !found {
+			return fmt.Sprintf("member %s is not in the domain of dimension %s", value.member.Name, dim.Element.Name)
+		}
+		if !usable {
+			return fmt.Sprintf("member %s of dimension %s is not usable", value.member.Name, dim.Element.Name)
+		}
+	}
+
+	if hc.Closed {
+		for _, d := range inContainer {
+			if !hcDims[d.dimension] {
+				return fmt.Sprintf("dimension %s is not allowed in closed hypercube", d.dimension.Name)
+			}
+		}
+	}
+	return ""
+}
+
+func containerName(hc *resolver.Hypercube) string {
+	if hc.ContextElement == "" {
+		return "context"
+	}
+	return hc.ContextElement
+}
+
+var typedMemberDatePattern = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+
+// 型付きメンバーの内容が、typedDomainRef の要素と型に合っているか
+func checkTypedMember(dim *model.XMLElement, content string, nsMap map[string]string, elementsByHref map[string]*model.XMLElement) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	var name xml.Name
+	var text strings.Builder
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if name.Local != "" {
+					return fmt.Errorf("more than one element")
+				}
+				name = t.Name
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		}
+	}
+	if name.Local == "" {
+		return fmt.Errorf("no content element")
+	}
+
+	domain, ok := elementsByHref[resolver.ResolveTypedDomainRef(dim)]
+	if !ok {
+		return fmt.Errorf("typed domain %s not found", dim.TypedDomainRef)
+	}
+
+	// 名前空間が未宣言のプレフィックスはインスタンスの宣言で解決する
+	if uri, ok := nsMap[name.Space]; ok {
+		name.Space = uri
+	}
+	if name.Local != domain.Name || (name.Space != "" && name.Space != domain.Schema.TargetNS) {
+		return fmt.Errorf("element %s does not match typed domain %s", name.Local, domain.Name)
+	}
+
+	return checkSimpleValue(domain.Type, strings.TrimSpace(text.String()))
+}
+
+var (
+	integerLexicalPattern = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	decimalLexicalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	floatLexicalPattern   = regexp.MustCompile(`^([+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?|-?INF|NaN)$`)
+)
+
+// 整数型の値の範囲（nil は上限・下限なし）
+type integerRange struct {
+	min, max *big.Int
+}
+
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+var integerRanges = map[string]integerRange{
+	"integer":            {},
+	"long":               {bigInt("-9223372036854775808"), bigInt("9223372036854775807")},
+	"int":                {bigInt("-2147483648"), bigInt("2147483647")},
+	"short":              {bigInt("-32768"), bigInt("32767")},
+	"byte":               {bigInt("-128"), bigInt("127")},
+	"nonNegativeInteger": {bigInt("0"), nil},
+	"positiveInteger":    {bigInt("1"), nil},
+	"nonPositiveInteger": {nil, bigInt("0")},
+	"negativeInteger":    {nil, bigInt("-1")},
+	"unsignedLong":       {bigInt("0"), bigInt("18446744073709551615")},
+	"unsignedInt":        {bigInt("0"), bigInt("4294967295")},
+	"unsignedShort":      {bigInt("0"), bigInt("65535")},
+	"unsignedByte":       {bigInt("0"), bigInt("255")},
+}
+
+// 整数の字句と、型ごとの値の範囲を確認する
+func checkInteger(r integerRange, value string) error {
+	if !integerLexicalPattern.MatchString(value) {
+		return fmt.Errorf("invalid integer")
+	}
+	n := bigInt(value)
+	if (r.min != nil && n.Cmp(r.min) < 0) || (r.max != nil && n.Cmp(r.max) > 0) {
+		return fmt.Errorf("out of range")
+	}
+	return nil
+}
+
+// XML Schema の組込み単純型の字句を確認する（それ以外の型は確認しない）
+func checkSimpleValue(typeName, value string) error {
+	local := typeName[strings.LastIndex(typeName, ":")+1:]
+	var err error
+	switch local {
+	case "integer", "int", "long", "short", "byte",
+		"nonNegativeInteger", "positiveInteger", "nonPositiveInteger", "negativeInteger",
+		"unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte":
+		err = checkInteger(integerRanges[local], value)
+	case "decimal":
+		if !decimalLexicalPattern.MatchString(value) {
+			err = fmt.Errorf("invalid decimal")
+		}
+	case "float", "double":
+		if !floatLexicalPattern.MatchString(value) {
+			err = fmt.Errorf("invalid %s", local)
+		}
+	case "boolean":
+		switch value {
+		case "true", "false", "1", "0":
+		default:
+			err = fmt.Errorf("invalid boolean")
+		}
+	case "date":
+		if !typedMemberDatePattern.MatchString(value) {
+			err = fmt.Errorf("invalid date")
+		}
+	case "anyURI":
+		_, err = url.Parse(value)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, local)
+	}
+	return nil
+}